@@ -14,15 +14,24 @@ var checkoutCmd = &cobra.Command{
 	Short: "Checkout a branch from a stack",
 	Long: `Checkout a branch from a stack.
 If a number is given, checkout the branch by its number in the stack (see status command).
-If a name is given, checkout the branch by its name.`,
+If a name is given, checkout the branch by its name.
+If no argument is given, pick the branch in a list filtered while typing (terminal only).`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		if n, errParse := strconv.Atoi(args[0]); errParse == nil {
-			err = stacksManager().CheckoutByNumber(n)
-		} else {
-			err = stacksManager().CheckoutByName(args[0])
+		if len(args) > 0 {
+			if n, errParse := strconv.Atoi(args[0]); errParse == nil {
+				return stacksManager().CheckoutByNumber(n)
+			}
 		}
-		return err
+
+		// A picked branch is a name, even when it looks like a number (e.g. an issue number)
+		branch, err := pickArgument(args, "branch", func() []string {
+			return stacksManager().ListBranchesForCompletion("")
+		})
+		if err != nil {
+			return err
+		}
+		return stacksManager().CheckoutByName(branch)
 	},
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return stacksManager().ListBranchesForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
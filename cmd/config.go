@@ -0,0 +1,32 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set the configuration",
+	Long: `Get and set the configuration.
+The configuration is read from these layers, each one overriding the previous:
+  1. user:  ~/.config/gostacking/config.toml (see --config)
+  2. repo:  .gostacking.toml at the root of the repository
+  3. git:   git config gostacking.<key>
+
+Keys:
+  remote              Remote of the branches (default origin)
+  color               When to use colors: auto, always or never (default auto)
+  backend             Read the repository with the git command (cli) or go-git (default cli)
+  sync.strategy       How sync pulls each branch: auto, merge, rebase or ff-only (default auto)
+  sync.push           Default of sync --push (default false)
+  sync.merge-default  Default of sync --merge-default (default false)
+  forge.<host>        Forge of a remote host, e.g. forge.github.mycompany.com github`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,22 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Show the value of a key after all the layers",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stacksManager().ConfigGet(args[0])
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+}
@@ -0,0 +1,22 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all keys with their value and the layer it comes from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stacksManager().ConfigList()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a key in the git config, or in the user or repository file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		user, _ := cmd.Flags().GetBool("user")
+		repo, _ := cmd.Flags().GetBool("repo")
+		if user && repo {
+			return errors.New("--user and --repo can't be used together")
+		}
+
+		layer := config.LayerGit
+		if user {
+			layer = config.LayerUser
+		} else if repo {
+			layer = config.LayerRepo
+		}
+		return stacksManager().ConfigSet(layer, args[0], args[1])
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+
+	configSetCmd.Flags().BoolP("user", "u", false, "Set in the user file ~/.config/gostacking/config.toml.")
+	configSetCmd.Flags().BoolP("repo", "r", false, "Set in the repository file .gostacking.toml, to share it with the team.")
+}
@@ -11,8 +11,20 @@ import (
 var deleteCmd = &cobra.Command{
 	Use:   "delete [stack]",
 	Short: "Delete a gostacking by is name",
+	Long: `Delete a gostacking by is name.
+If no argument is given, pick the stack in a list filtered while typing (terminal only).`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stacksManager().Delete(args[0])
+		stackName, err := pickArgument(args, "stack", func() []string {
+			return stacksManager().ListStacksForCompletion("")
+		})
+		if err != nil {
+			return err
+		}
+		return stacksManager().Delete(stackName)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return stacksManager().ListStacksForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
 	},
 }
 
@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/Bhacaz/gostacking/internal/stack"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [branch or number]",
+	Short: "Show the changes of a branch of the current stack since its parent.",
+	Long: `Show the changes of a branch of the current stack since its parent in the stack,
+or the default branch for the first one, like git diff parent...branch.
+If a number is given, the branch by its number in the stack (see status command).
+If no argument is given, the current branch.
+The diff is shown in the pager like git diff, use --no-pager to print it.
+
+Use --since to show what changed in each branch since an older version instead, after a sync or a rewrite.
+Each branch is compared like git range-diff between its commits at the time of --since and now.
+The old versions come from the reflog, --since is a date or the number of a reflog entry, e.g.:
+  gostacking diff --since yesterday
+  gostacking diff --since "2024-01-01 10:00"
+  gostacking diff --since 1
+The merges and the commits coming from the parent are ignored, only the commits of the branch itself are compared.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch := ""
+		if len(args) > 0 {
+			branch = args[0]
+		}
+		stat, _ := cmd.Flags().GetBool("stat")
+		nameOnly, _ := cmd.Flags().GetBool("name-only")
+		noPager, _ := cmd.Flags().GetBool("no-pager")
+		since, _ := cmd.Flags().GetString("since")
+		return stacksManager().Diff(stack.DiffOptions{
+			Branch:   branch,
+			Stat:     stat,
+			NameOnly: nameOnly,
+			NoPager:  noPager,
+			Since:    since,
+		})
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return stacksManager().ListBranchesForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().Bool("stat", false, "Only show the files changed, insertions and deletions")
+	diffCmd.Flags().Bool("name-only", false, "Only show the names of the files changed")
+	diffCmd.Flags().Bool("no-pager", false, "Print the diff instead of showing it in the pager")
+	diffCmd.Flags().String("since", "", "Date or reflog entry of the versions to compare each branch with, e.g. \"yesterday\" or 1")
+}
@@ -0,0 +1,29 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the stacks for problems and offer to fix them",
+	Long: `Check the stacks for problems and offer to fix them.
+The following problems are detected:
+- The current stack does not exist
+- A branch of a stack does not exist locally
+- A branch of a stack has no upstream
+- A branch is in more than one stack
+- The default branch origin/HEAD is not set`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stacksManager().Doctor()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,31 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the current stack in your editor",
+	Long: `Edit the current stack in your editor.
+The branches of the current stack are opened as a todo file, one branch per line.
+Lines can be reordered and each line starts with a command:
+
+keep <branch>               keep the branch in the stack
+drop <branch>               remove the branch from the stack
+rename <branch> <new-name>  rename the branch and keep it in the stack
+
+The changes are applied once the editor is closed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stacksManager().Edit()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
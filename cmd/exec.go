@@ -0,0 +1,37 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/Bhacaz/gostacking/internal/stack"
+	"github.com/spf13/cobra"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec -- [command]",
+	Short: "Run a command on each branch of the current stack",
+	Long: `Run a command on each branch of the current stack and show which branches pass or fail.
+Each branch is checked out in a temporary worktree, the current working directory is left untouched.
+A single argument is run with sh, e.g. gostacking exec -- "make build && make test".
+
+The command gets the environment variables GOSTACKING_STACK, GOSTACKING_BRANCH,
+GOSTACKING_PARENT (empty for the first branch) and GOSTACKING_POSITION.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		return stacksManager().ExecEach(args, stack.ExecOptions{
+			FailFast: failFast,
+			Parallel: parallel,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().BoolP("fail-fast", "f", false, "Skip the remaining branches after the first failure.")
+	execCmd.Flags().IntP("parallel", "p", 1, "Number of branches running the command at the same time.")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/Bhacaz/gostacking/internal/stack"
+	"github.com/spf13/cobra"
+)
+
+// foldCmd represents the fold command
+var foldCmd = &cobra.Command{
+	Use:   "fold [branch or number]",
+	Short: "Merge a branch of the current stack into its parent and remove it from the stack",
+	Long: `Merge a branch of the current stack into its parent and remove it from the stack,
+e.g. when a review asks to combine two branches. The next branch then follows the parent.
+If a number is given, the branch by its number in the stack (see status command).
+If no argument is given, the current branch. The git branch is kept.
+
+With GH-CLI, --close-pr closes the pull request of the branch
+and --retarget changes the base of the pull request of the next branch to the parent,
+it needs --push so the parent has the changes of the branch on the remote.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch := ""
+		if len(args) > 0 {
+			branch = args[0]
+		}
+		push, _ := cmd.Flags().GetBool("push")
+		closePr, _ := cmd.Flags().GetBool("close-pr")
+		retarget, _ := cmd.Flags().GetBool("retarget")
+		return stacksManager().Fold(stack.FoldOptions{
+			Branch:   branch,
+			Push:     push,
+			ClosePr:  closePr,
+			Retarget: retarget,
+		})
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return stacksManager().ListBranchesForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(foldCmd)
+
+	foldCmd.Flags().BoolP("push", "p", false, "Push the parent after the merge.")
+	foldCmd.Flags().Bool("close-pr", false, "Close the pull request of the branch.")
+	foldCmd.Flags().Bool("retarget", false, "Change the base of the pull request of the next branch to the parent, needs --push.")
+}
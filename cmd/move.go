@@ -0,0 +1,44 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+// moveCmd represents the move command
+var moveCmd = &cobra.Command{
+	Use:   "move [branch or number] [position]",
+	Short: "Move a branch to another position in the current stack",
+	Long: `Move a branch to another position in the current stack.
+If a number is given, move the branch by its number in the stack (see status command).
+If a name is given, move the branch by its name.
+
+A warning is shown when a branch already contains commits from its new child.
+After moving, a sync of the stack is offered.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		position, err := strconv.Atoi(args[1])
+		if err != nil || position < 1 {
+			return errors.New("invalid position " + args[1] + ", it must be greater than or equal to 1")
+		}
+
+		if n, errParse := strconv.Atoi(args[0]); errParse == nil {
+			return stacksManager().MoveByNumber(n, position)
+		}
+		return stacksManager().MoveByName(args[0], position)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return stacksManager().ListBranchesForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+}
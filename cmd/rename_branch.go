@@ -0,0 +1,38 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// renameBranchCmd represents the rename-branch command
+var renameBranchCmd = &cobra.Command{
+	Use:   "rename-branch [branch] [new name]",
+	Short: "Rename a branch and keep the stacks consistent",
+	Long: `Rename a branch and keep the stacks consistent.
+The git branch is renamed and every stack that contains it is updated.
+
+With the --remote flag, the remote branch is renamed too.
+If the branch has a pull request and GH-CLI is available, the branch is renamed on GitHub
+so the pull request follows the renamed branch. Otherwise the new branch is pushed
+and the old remote branch is deleted.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		renameRemote, _ := cmd.Flags().GetBool("remote")
+		return stacksManager().RenameBranch(args[0], args[1], renameRemote)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return stacksManager().ListBranchesForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameBranchCmd)
+
+	renameBranchCmd.Flags().BoolP("remote", "r", false, "Rename the remote branch too.")
+}
@@ -0,0 +1,28 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// renameStackCmd represents the rename-stack command
+var renameStackCmd = &cobra.Command{
+	Use:   "rename-stack [stack] [new name]",
+	Short: "Rename a stack",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return stacksManager().RenameStack(args[0], args[1])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return stacksManager().ListStacksForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameStackCmd)
+}
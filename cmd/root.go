@@ -4,12 +4,36 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/cliexec"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/config"
+	"github.com/Bhacaz/gostacking/internal/logger"
+	"github.com/Bhacaz/gostacking/internal/prompt"
 	"github.com/Bhacaz/gostacking/internal/stack"
+	"github.com/Bhacaz/gostacking/internal/terminal"
 	"github.com/spf13/cobra"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 var Verbose bool
+var Output string
+var ColorMode string
+var RecordFile string
+var TraceFile string
+var LogLevel string
+var ConfigFile string
+
+// loadedConfig gives the defaults of the flags, it is loaded before running a command
+var loadedConfig *config.Config
+
+var commandsLogger *slog.Logger
+var closeTrace = func() error { return nil }
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -18,6 +42,38 @@ var rootCmd = &cobra.Command{
 	Long: `Git stacking with merge.
 Written in go.`,
 	Version: "v0.10.1",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if Output != stack.OutputText && Output != stack.OutputJSON {
+			return errors.New("invalid output " + Output + ", must be text or json")
+		}
+
+		level, err := logger.ParseLevel(LogLevel)
+		if err != nil {
+			return err
+		}
+		commandsLogger, closeTrace, err = logger.New(Verbose, TraceFile, level, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		loader := config.Loader{
+			UserFile:    ConfigFile,
+			GitExecutor: cliexec.NewExecutor("git", commandsLogger).WithContext(cmd.Context()),
+		}
+		cfg, err := loader.Load()
+		// The config commands must work to fix an invalid config
+		if err != nil && cmd.Parent() != configCmd {
+			return err
+		}
+		if err == nil {
+			loadedConfig = &cfg
+		}
+
+		if !cmd.Flags().Changed("color") && loadedConfig != nil {
+			ColorMode = loadedConfig.Color
+		}
+		return color.SetMode(ColorMode)
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -25,8 +81,21 @@ Written in go.`,
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The first Ctrl-C cancels the context so commands stop cleanly, a second one quits right away.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	start := time.Now()
+	err := rootCmd.ExecuteContext(ctx)
+	if commandsLogger != nil {
+		commandsLogger.Info("done", "args", os.Args[1:], "duration", time.Since(start))
+	}
+	_ = closeTrace()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -37,8 +106,14 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gostacking.yaml)")
-	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "Display all Git commands run under the hood")
+	rootCmd.PersistentFlags().StringVar(&ConfigFile, "config", config.DefaultUserFile(), "User config file")
+	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "Display all Git commands run under the hood with their duration")
+	rootCmd.PersistentFlags().StringVar(&TraceFile, "trace-file", "", "Append all Git and GH-CLI commands with their duration, exit code and output to a file as JSON lines")
+	rootCmd.PersistentFlags().StringVar(&LogLevel, "log-level", "debug", "Minimum level of the logs of --verbose and --trace-file: debug (every command), info (failed commands) or error (interrupted and timed out commands)")
+	rootCmd.PersistentFlags().StringVarP(&Output, "output", "o", stack.OutputText, "Output format of status, list and tree: text or json")
+	rootCmd.PersistentFlags().StringVar(&ColorMode, "color", color.ModeAuto, "When to use colors: auto, always or never. Auto respects NO_COLOR")
+	rootCmd.PersistentFlags().StringVar(&RecordFile, "record", "", "Record all Git and GH-CLI commands to a fixture file for tests")
+	_ = rootCmd.PersistentFlags().MarkHidden("record")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -47,5 +122,32 @@ func init() {
 }
 
 func stacksManager() stack.StacksManager {
-	return stack.NewManager(Verbose)
+	return stack.NewManager(stack.Options{
+		Logger:         commandsLogger,
+		Output:         Output,
+		Context:        rootCmd.Context(),
+		RecordFile:     RecordFile,
+		Config:         loadedConfig,
+		UserConfigFile: ConfigFile,
+	})
+}
+
+// pickArgument returns the first argument. When it is omitted, the user picks one of the items
+// (e.g. branch or stack) on a terminal, it is an error otherwise.
+func pickArgument(args []string, item string, items func() []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	tty, err := terminal.Open()
+	if err != nil {
+		return "", errors.New("missing " + item + " argument, it can only be picked on a terminal")
+	}
+	defer tty.Close()
+
+	choices := items()
+	if len(choices) == 0 {
+		return "", errors.New("no " + item + " to pick")
+	}
+	return prompt.Pick(tty, "Pick a "+item, choices)
 }
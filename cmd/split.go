@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/Bhacaz/gostacking/internal/stack"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+// splitCmd represents the split command
+var splitCmd = &cobra.Command{
+	Use:   "split [branch or number]",
+	Short: "Split a branch of the current stack in two stacked branches",
+	Long: `Split a branch of the current stack in two stacked branches, e.g. to split a big pull request.
+The new branch has the first part of the changes and is inserted before the branch in the stack.
+If a number is given, the branch by its number in the stack (see status command).
+If no argument is given, the current branch.
+
+With --at, the new branch ends at a commit of the branch, the commits after it stay in the branch.
+Without --at nor --path, pick the commit in a list filtered while typing (terminal only).
+With --path, the changes of the files matching the globs are committed on the new branch,
+then merged in the branch. The history is never rewritten.
+  gostacking split --at abc1234 --name feature/api
+  gostacking split 2 --path "internal/api/**" --name feature/api`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch := ""
+		if len(args) > 0 {
+			branch = args[0]
+		}
+		at, _ := cmd.Flags().GetString("at")
+		paths, _ := cmd.Flags().GetStringArray("path")
+		name, _ := cmd.Flags().GetString("name")
+
+		if at == "" && len(paths) == 0 {
+			commit, err := pickArgument(nil, "commit", func() []string {
+				return stacksManager().ListCommitsForSplit(branch)
+			})
+			if err != nil {
+				return err
+			}
+			at, _, _ = strings.Cut(commit, " ")
+		}
+
+		return stacksManager().Split(stack.SplitOptions{
+			Branch: branch,
+			At:     at,
+			Paths:  paths,
+			Name:   name,
+		})
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return stacksManager().ListBranchesForCompletion(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+
+	splitCmd.Flags().String("at", "", "Last commit of the new branch, the commits after it stay in the branch")
+	splitCmd.Flags().StringArray("path", nil, "Move the changes of the files matching a glob to the new branch, e.g. \"internal/api/**\" (repeatable)")
+	splitCmd.Flags().StringP("name", "n", "", "Name of the new branch (default <branch>-part1)")
+}
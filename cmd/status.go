@@ -13,7 +13,10 @@ var statusCmd = &cobra.Command{
 	Short: "Get current stack",
 	Long: `Get current stack.
 Show the current stack and the current branch.
-Branches out of sync with the previous branch are marked with a star (*).
+Each branch shows its number of commits:
+  ↓ behind its remote branch
+  ↑ ahead of its remote branch
+  ⇣ behind the previous branch (red), or the default branch for the first one (magenta)
 Add the --log flag to show the last commit log for each branch in the stack.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		showLogValue, _ := cmd.Flags().GetBool("log")
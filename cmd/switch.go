@@ -15,10 +15,21 @@ var switchCmd = &cobra.Command{
 	Long: `Change the current stack.
 If a number is given, switch to the stack by its number in the list of stacks (see list command).
 If a name is given, switch to the stack by its name.
-If no argument is given, switch to the stack that contains the current branch.`,
+If no argument is given, switch to the stack that contains the current branch.
+With --pick, pick the stack in a list filtered while typing (terminal only).`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		pick, _ := cmd.Flags().GetBool("pick")
 		var err error
-		if len(args) == 0 {
+		if pick && len(args) == 0 {
+			stackName, pickErr := pickArgument(args, "stack", func() []string {
+				return stacksManager().ListStacksForCompletion("")
+			})
+			if pickErr != nil {
+				return pickErr
+			}
+			err = stacksManager().SwitchByName(stackName)
+		} else if len(args) == 0 {
 			err = stacksManager().SwitchByName("")
 		} else if n, parseErr := strconv.Atoi(args[0]); parseErr == nil {
 			err = stacksManager().SwitchByNumber(n)
@@ -44,4 +55,6 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// switchCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+
+	switchCmd.Flags().BoolP("pick", "p", false, "Pick the stack in a list filtered while typing.")
 }
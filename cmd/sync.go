@@ -14,10 +14,17 @@ var syncCmd = &cobra.Command{
 	Long: `Merge all branches into the others.
 This command will merge all branches into the others, starting from the bottom of the stack.
 The current git status must be clean before running this command.
-Each branch will be pulled to prevent conflict with the remote.`,
+Each branch will be pulled to prevent conflict with the remote.
+Ctrl-C stops the sync before the next branch, run it again to finish syncing the stack.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		pushValue, _ := cmd.Flags().GetBool("push")
 		mergeDefaultBranch, _ := cmd.Flags().GetBool("merge-default")
+		if loadedConfig != nil && !cmd.Flags().Changed("push") {
+			pushValue = loadedConfig.Sync.Push
+		}
+		if loadedConfig != nil && !cmd.Flags().Changed("merge-default") {
+			mergeDefaultBranch = loadedConfig.Sync.MergeDefault
+		}
 		return stacksManager().Sync(pushValue, mergeDefaultBranch)
 	},
 }
@@ -34,6 +41,6 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// syncCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	syncCmd.Flags().BoolP("push", "p", false, "Push commits after syncing.")
-	syncCmd.Flags().BoolP("merge-default", "m", false, "Merge the default branch into the first branch of the stack.")
+	syncCmd.Flags().BoolP("push", "p", false, "Push commits after syncing. Default from the config sync.push.")
+	syncCmd.Flags().BoolP("merge-default", "m", false, "Merge the default branch into the first branch of the stack. Default from the config sync.merge-default.")
 }
@@ -4,6 +4,7 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"github.com/Bhacaz/gostacking/internal/stack"
 	"github.com/spf13/cobra"
 )
 
@@ -13,9 +14,37 @@ var treeCmd = &cobra.Command{
 	Short: "Show the stack tree without merged commits, starting from the default branch.",
 	Long: `Show the stack tree without merged commits, starting from the default branch.
 
-Allow to clearly see the stack and every important information about each branch.`,
+Allow to clearly see the stack and every important information about each branch.
+
+Use --format dot or --format mermaid to export the stack as a graph, with the number of commits,
+the status (see status) and the pull request number of each branch, e.g.:
+  gostacking tree --format dot | dot -Tsvg > stack.svg
+Use --all to show every stack.
+
+Each branch shows its number of commits, files changed, insertions and deletions since its parent.
+Use --stat to show them for each commit, and --author, --since or --path to only show some commits, e.g.:
+  gostacking tree --stat --since "2 weeks ago" --path "internal/**" --path "*.md"
+
+Use --show-syncs to also show the merge commits, mark the last sync of each branch
+and flag the branches whose parent moved since their last sync.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stacksManager().Tree()
+		format, _ := cmd.Flags().GetString("format")
+		all, _ := cmd.Flags().GetBool("all")
+		stat, _ := cmd.Flags().GetBool("stat")
+		author, _ := cmd.Flags().GetString("author")
+		since, _ := cmd.Flags().GetString("since")
+		paths, _ := cmd.Flags().GetStringArray("path")
+		showSyncs, _ := cmd.Flags().GetBool("show-syncs")
+		return stacksManager().Tree(stack.TreeOptions{
+			Format: format,
+			All:    all,
+			Stat:   stat,
+			Author: author,
+			Since:  since,
+			Paths:  paths,
+
+			ShowSyncs: showSyncs,
+		})
 	},
 }
 
@@ -31,4 +60,11 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// treeCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	treeCmd.Flags().StringP("format", "f", stack.TreeFormatText, "Format of the tree: text, dot (Graphviz) or mermaid")
+	treeCmd.Flags().BoolP("all", "a", false, "Show every stack instead of the current one")
+	treeCmd.Flags().Bool("stat", false, "Show the files changed, insertions and deletions of each commit")
+	treeCmd.Flags().String("author", "", "Only show the commits of an author (like git log --author)")
+	treeCmd.Flags().String("since", "", "Only show the commits more recent than a date, e.g. \"2 weeks ago\" (like git log --since)")
+	treeCmd.Flags().StringArray("path", nil, "Only show the commits changing files matching a glob, e.g. \"internal/**\" (repeatable)")
+	treeCmd.Flags().Bool("show-syncs", false, "Show the merge commits, the last sync of each branch and if its parent moved since")
 }
@@ -0,0 +1,43 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/terminal"
+	"github.com/spf13/cobra"
+)
+
+// uiCmd represents the ui command
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse and manage the stacks in an interactive terminal UI",
+	Long: `Browse and manage the stacks in an interactive terminal UI.
+The stacks are on the left, the branches of the selected stack on the right
+with their status (see status) and the commits of the selected branch.
+Keys:
+  ↑↓ or j/k   Select a stack or a branch
+  ←→ or h/l   Select the stacks or the branches pane (also tab)
+  enter       Switch to the selected stack, or checkout the selected branch
+  c           Checkout the selected branch
+  s           Sync the selected stack
+  K/J         Move the selected branch up or down in its stack
+  x           Remove the selected branch from its stack
+  p           Publish the selected branch
+  r           Fetch and refresh
+  q           Quit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tty, err := terminal.Open()
+		if err != nil {
+			return errors.New("the ui needs a terminal: " + err.Error())
+		}
+		defer tty.Close()
+		return stacksManager().UI(tty)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"bytes"
+	"github.com/Bhacaz/gostacking/internal/cliexec"
+	"github.com/Bhacaz/gostacking/internal/config"
+	"github.com/Bhacaz/gostacking/internal/stack"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo is a clone of a bare "origin" repository, both in a temporary directory.
+type testRepo struct {
+	t      *testing.T
+	dir    string
+	origin string
+	out    *bytes.Buffer
+}
+
+// newTestRepo creates the origin with a commit on main, clones it and sets origin/HEAD.
+// The user and system git config are ignored so the tests behave the same everywhere.
+func newTestRepo(t *testing.T) *testRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "John Doe")
+	t.Setenv("GIT_AUTHOR_EMAIL", "john@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "John Doe")
+	t.Setenv("GIT_COMMITTER_EMAIL", "john@example.com")
+	t.Setenv("NO_COLOR", "1")
+
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	repo := &testRepo{
+		t:      t,
+		dir:    filepath.Join(root, "repo"),
+		origin: filepath.Join(root, "origin.git"),
+		out:    &bytes.Buffer{},
+	}
+
+	repo.run(root, "init", "--bare", "--initial-branch=main", repo.origin)
+	repo.run(root, "clone", repo.origin, repo.dir)
+	repo.git("symbolic-ref", "HEAD", "refs/heads/main")
+	repo.commit("README.md", "# Test\n", "Initial commit")
+	repo.git("push", "-u", "origin", "main")
+	repo.git("remote", "set-head", "origin", "--auto")
+	return repo
+}
+
+func (r *testRepo) run(dir string, args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// git runs a git command in the clone and fails the test on error.
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	return r.run(r.dir, args...)
+}
+
+// commit writes content to file and commits it on the current branch.
+func (r *testRepo) commit(file string, content string, message string) string {
+	r.t.Helper()
+	err := os.WriteFile(filepath.Join(r.dir, file), []byte(content), 0644)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.git("add", file)
+	r.git("commit", "-m", message)
+	return r.git("rev-parse", "HEAD")
+}
+
+// branch creates a branch from the current one with a commit changing file.
+func (r *testRepo) branch(name string, file string) {
+	r.t.Helper()
+	r.git("checkout", "-b", name)
+	r.commit(file, name+"\n", "Add "+file+" in "+name)
+}
+
+// manager returns a new StacksManager on the clone with its config, like each gostacking command does.
+func (r *testRepo) manager() stack.StacksManager {
+	r.t.Helper()
+	loader := config.Loader{
+		UserFile:    config.DefaultUserFile(),
+		GitExecutor: cliexec.NewExecutor("git", nil).WithDir(r.dir),
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return stack.NewManager(stack.Options{
+		Output: stack.OutputText,
+		Dir:    r.dir,
+		Out:    r.out,
+		Config: &cfg,
+	})
+}
+
+// output returns what was printed since the last call.
+func (r *testRepo) output() string {
+	output := r.out.String()
+	r.out.Reset()
+	return output
+}
+
+func (r *testRepo) isAncestor(ancestor string, branch string) bool {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", ancestor, branch)
+	cmd.Dir = r.dir
+	return cmd.Run() == nil
+}
+
+func (r *testRepo) assertNoError(err error) {
+	r.t.Helper()
+	if err != nil {
+		r.t.Fatalf("show have no error, got %s", err)
+	}
+}
+
+func (r *testRepo) assertContains(got string, want string) {
+	r.t.Helper()
+	if !strings.Contains(got, want) {
+		r.t.Errorf("got \"%s\", want \"%s\"", got, want)
+	}
+}
+
+// stackedRepo creates the stack "stack1" with feature1 and feature2 on top of main.
+func stackedRepo(t *testing.T) *testRepo {
+	repo := newTestRepo(t)
+	repo.branch("feature1", "feature1.txt")
+	repo.assertNoError(repo.manager().CreateStack("stack1"))
+	repo.branch("feature2", "feature2.txt")
+	repo.assertNoError(repo.manager().AddBranch("", 0))
+	repo.output()
+	return repo
+}
@@ -0,0 +1,221 @@
+package integration
+
+import (
+	"encoding/json"
+	"github.com/Bhacaz/gostacking/internal/stack"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCreateAndAdd(t *testing.T) {
+	repo := stackedRepo(t)
+
+	data, err := os.ReadFile(filepath.Join(repo.dir, ".git", "gostacking.json"))
+	repo.assertNoError(err)
+	var stacks struct {
+		CurrentStack string `json:"currentStack"`
+		Stacks       []struct {
+			Name     string   `json:"name"`
+			Branches []string `json:"branches"`
+		} `json:"stacks"`
+	}
+	repo.assertNoError(json.Unmarshal(data, &stacks))
+
+	if stacks.CurrentStack != "stack1" {
+		t.Errorf("got %s, want %s", stacks.CurrentStack, "stack1")
+	}
+	want := []string{"feature1", "feature2"}
+	if len(stacks.Stacks) != 1 || !reflect.DeepEqual(stacks.Stacks[0].Branches, want) {
+		t.Errorf("got %v, want %v", stacks.Stacks, want)
+	}
+
+	repo.assertNoError(repo.manager().List())
+	repo.assertContains(repo.output(), "Current stack: stack1\n1. stack1")
+}
+
+func TestSync(t *testing.T) {
+	repo := stackedRepo(t)
+	repo.git("push", "-u", "origin", "feature1")
+	repo.git("push", "-u", "origin", "feature2")
+
+	// main moves on the remote and feature1 gets a new commit
+	repo.git("checkout", "main")
+	mainCommit := repo.commit("main.txt", "main\n", "Add main.txt")
+	repo.git("push", "origin", "main")
+	repo.git("reset", "--hard", "HEAD~1")
+	repo.git("checkout", "feature1")
+	feature1Commit := repo.commit("feature1.txt", "updated\n", "Update feature1.txt")
+	repo.git("checkout", "feature2")
+
+	err := repo.manager().Sync(true, true)
+	repo.assertNoError(err)
+
+	if !repo.isAncestor(mainCommit, "feature1") {
+		t.Errorf("origin/main should have been merged into feature1")
+	}
+	if !repo.isAncestor(feature1Commit, "feature2") {
+		t.Errorf("feature1 should have been merged into feature2")
+	}
+	if got := repo.git("log", "-1", "--format=%s", "feature2"); got != "Merge branch feature1 into feature2 (gostacking)" {
+		t.Errorf("got %s, want %s", got, "Merge branch feature1 into feature2 (gostacking)")
+	}
+	for _, branch := range []string{"feature1", "feature2"} {
+		local := repo.git("rev-parse", branch)
+		remote := repo.run(repo.origin, "rev-parse", branch)
+		if local != remote {
+			t.Errorf("%s: got %s on origin, want %s", branch, remote, local)
+		}
+	}
+	if got := repo.git("rev-parse", "--abbrev-ref", "HEAD"); got != "feature2" {
+		t.Errorf("got %s, want %s", got, "feature2")
+	}
+}
+
+func TestSyncWithConflict(t *testing.T) {
+	repo := stackedRepo(t)
+	repo.commit("shared.txt", "feature2\n", "Add shared.txt in feature2")
+	repo.git("checkout", "feature1")
+	repo.commit("shared.txt", "feature1\n", "Add shared.txt in feature1")
+
+	err := repo.manager().Sync(false, false)
+
+	if err == nil {
+		t.Fatalf("got none, want Error")
+	}
+	repo.assertContains(err.Error(), "CONFLICT (add/add): Merge conflict in shared.txt")
+	if got := repo.git("rev-parse", "--abbrev-ref", "HEAD"); got != "feature2" {
+		t.Errorf("got %s, want %s", got, "feature2")
+	}
+	if _, err := os.Stat(filepath.Join(repo.dir, ".git", "MERGE_HEAD")); err != nil {
+		t.Errorf("the merge should be left in progress, got %s", err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	repo := stackedRepo(t)
+
+	err := repo.manager().Publish()
+	repo.assertNoError(err)
+
+	repo.assertContains(repo.output(), "Publishing feature2...\nRemote is not on GitHub. Sorry.")
+	if got := repo.git("rev-parse", "--abbrev-ref", "feature2@{upstream}"); got != "origin/feature2" {
+		t.Errorf("got %s, want %s", got, "origin/feature2")
+	}
+	if local, remote := repo.git("rev-parse", "feature2"), repo.run(repo.origin, "rev-parse", "feature2"); local != remote {
+		t.Errorf("got %s on origin, want %s", remote, local)
+	}
+}
+
+func TestTree(t *testing.T) {
+	repo := stackedRepo(t)
+	repo.commit("feature2.txt", "updated\n", "Update feature2.txt")
+
+	err := repo.manager().Tree(stack.TreeOptions{})
+	repo.assertNoError(err)
+
+	output := repo.output()
+	lines := strings.Split(output, "\n")
+	var branchesAndSubjects []string
+	for _, line := range lines {
+		for _, want := range []string{"* feature1", "* feature2", "Add feature1.txt in feature1", "Add feature2.txt in feature2", "Update feature2.txt"} {
+			if strings.Contains(line, want) {
+				branchesAndSubjects = append(branchesAndSubjects, want)
+			}
+		}
+	}
+	want := []string{"* feature1", "Add feature1.txt in feature1", "* feature2", "Add feature2.txt in feature2", "Update feature2.txt"}
+	if !reflect.DeepEqual(branchesAndSubjects, want) {
+		t.Errorf("got %v, want %v\n%s", branchesAndSubjects, want, output)
+	}
+}
+
+// The go-git backend must report the same status as the git CLI.
+func TestStatusBackends(t *testing.T) {
+	repo := stackedRepo(t)
+	repo.git("push", "-u", "origin", "feature1")
+	repo.commit("feature2.txt", "updated\n", "Update feature2.txt")
+	repo.git("checkout", "feature1")
+	repo.commit("feature1.txt", "updated\n", "Update feature1.txt")
+
+	status := func() string {
+		manager := repo.manager()
+		repo.assertNoError(manager.CurrentStackStatus(false))
+		return repo.output()
+	}
+
+	cliStatus := status()
+	repo.git("config", "gostacking.backend", "go-git")
+	goGitStatus := status()
+
+	want := "Current stack: stack1\nBranches:\n1. feature1 ↑1\n2. feature2 ⇣1"
+	repo.assertContains(cliStatus, want)
+	if goGitStatus != cliStatus {
+		t.Errorf("got \"%s\", want \"%s\"", goGitStatus, cliStatus)
+	}
+}
+
+func TestExecEach(t *testing.T) {
+	repo := stackedRepo(t)
+
+	// feature1 doesn't have the file added by feature2
+	err := repo.manager().ExecEach([]string{"test -f feature2.txt"}, stack.ExecOptions{Parallel: 2})
+
+	if err == nil {
+		t.Fatalf("got none, want Error")
+	}
+	repo.assertContains(repo.output(), "Results for stack1:\n1. ✗ feature1\n2. ✓ feature2")
+	if got := repo.git("worktree", "list", "--porcelain"); strings.Count(got, "worktree ") != 1 {
+		t.Errorf("the temporary worktrees should have been removed, got %s", got)
+	}
+	if got := repo.git("rev-parse", "--abbrev-ref", "HEAD"); got != "feature2" {
+		t.Errorf("got %s, want %s", got, "feature2")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	repo := stackedRepo(t)
+	repo.git("config", "gostacking.hook.pre-publish", `test "$GOSTACKING_PARENT" != feature1`)
+
+	err := repo.manager().Publish()
+
+	if err == nil {
+		t.Fatalf("got none, want Error")
+	}
+	if _, err := exec.Command("git", "-C", repo.origin, "rev-parse", "--verify", "feature2").Output(); err == nil {
+		t.Errorf("feature2 should not have been published")
+	}
+}
+
+func TestSplitByPaths(t *testing.T) {
+	repo := stackedRepo(t)
+	// The diff config of the user must not change the patch moved to the new branch
+	repo.git("config", "diff.noprefix", "true")
+	repo.git("config", "color.diff", "always")
+	if err := os.Mkdir(filepath.Join(repo.dir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo.commit("api/a.go", "package api\n", "Add api/a.go in feature2")
+	repo.commit("docs.txt", "docs\n", "Add docs.txt in feature2")
+
+	err := repo.manager().Split(stack.SplitOptions{Paths: []string{"api/**"}, Name: "api"})
+	repo.assertNoError(err)
+
+	branches := repo.manager().ListBranchesForCompletion("")
+	want := []string{"feature1", "api", "feature2"}
+	if !reflect.DeepEqual(branches, want) {
+		t.Errorf("got %v, want %v", branches, want)
+	}
+	if got := repo.git("ls-tree", "-r", "--name-only", "api"); got != "README.md\napi/a.go\nfeature1.txt" {
+		t.Errorf("got %s, want the files of feature1 and api/a.go", got)
+	}
+	if !repo.isAncestor("api", "feature2") {
+		t.Errorf("api should be merged in feature2")
+	}
+	if got := repo.git("rev-parse", "--abbrev-ref", "HEAD"); got != "feature2" {
+		t.Errorf("got %s, want %s", got, "feature2")
+	}
+}
@@ -1,48 +1,165 @@
 package cliexec
 
 import (
-	"github.com/Bhacaz/gostacking/internal/printer"
+	"context"
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/logger"
+	"log/slog"
 	"os/exec"
+	"slices"
 	"strings"
+	"time"
 )
 
 type InterfaceCliExecutor interface {
 	Exec(command ...string) (string, error)
 }
 
+// InterfaceContextExecutor is an executor whose commands can be
+// cancelled by a context.
+type InterfaceContextExecutor interface {
+	InterfaceCliExecutor
+	ExecContext(ctx context.Context, command ...string) (string, error)
+}
+
+var _ InterfaceContextExecutor = Executor{}
+
+var (
+	ErrTimeout     = errors.New("command timed out")
+	ErrInterrupted = errors.New("command interrupted")
+)
+
+// Timeouts are the maximum durations of a single command.
+// A zero value means the default one.
+type Timeouts struct {
+	// Local applies to the git commands that don't talk to a remote
+	Local time.Duration
+	// Network applies to fetch, pull, push and to all gh commands
+	Network time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Local:   2 * time.Minute,
+	Network: 5 * time.Minute,
+}
+
+var networkGitCommands = []string{"fetch", "pull", "push", "ls-remote", "clone", "remote"}
+
+func (t Timeouts) timeout(baseCliCmd string, args []string) time.Duration {
+	if baseCliCmd != "git" || (len(args) > 0 && slices.Contains(networkGitCommands, args[0])) {
+		if t.Network == 0 {
+			return DefaultTimeouts.Network
+		}
+		return t.Network
+	}
+	if t.Local == 0 {
+		return DefaultTimeouts.Local
+	}
+	return t.Local
+}
+
 type Executor struct {
 	baseCliCmd string
-	verbose bool
-	printer printer.Printer
+	log        *slog.Logger
+	ctx        context.Context
+	timeouts   Timeouts
+	dir        string
 }
 
-func NewExecutor(baseCliCmd string, verbose bool) Executor {
+// NewExecutor logs every command to log, nil discards the logs.
+func NewExecutor(baseCliCmd string, log *slog.Logger) Executor {
+	if log == nil {
+		log = logger.Discard()
+	}
 	return Executor{
 		baseCliCmd: baseCliCmd,
-		verbose: verbose,
-		printer: printer.NewPrinter(),
+		log:        log,
+		ctx:        context.Background(),
+		timeouts:   DefaultTimeouts,
 	}
 }
 
-func (e Executor) println(a ...interface{}) {
-	if e.verbose {
-		e.printer.Println(a...)
-	}
+// WithContext returns a copy of the executor where Exec is bound to ctx.
+func (e Executor) WithContext(ctx context.Context) Executor {
+	e.ctx = ctx
+	return e
 }
 
+func (e Executor) WithTimeouts(timeouts Timeouts) Executor {
+	e.timeouts = timeouts
+	return e
+}
+
+// WithDir returns a copy of the executor running the commands in dir
+// instead of the current directory.
+func (e Executor) WithDir(dir string) Executor {
+	e.dir = dir
+	return e
+}
+
+// Exec can be called concurrently, each command is logged in one record
+// so the logs of concurrent commands are not mixed.
 func (e Executor) Exec(gitCmdArgs ...string) (string, error) {
-	e.println("CMD:\t", e.baseCliCmd, strings.Join(gitCmdArgs, " "))
+	return e.ExecContext(e.ctx, gitCmdArgs...)
+}
+
+// ExecContext kills the command when ctx is done or when it runs longer than its timeout.
+// The reason is appended to the output since callers report the output to the user.
+func (e Executor) ExecContext(ctx context.Context, gitCmdArgs ...string) (string, error) {
+	timeout := e.timeouts.timeout(e.baseCliCmd, gitCmdArgs)
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	execCmd := exec.Command(e.baseCliCmd, gitCmdArgs...)
+	commandLine := e.baseCliCmd + " " + strings.Join(gitCmdArgs, " ")
+	execCmd := exec.CommandContext(timeoutCtx, e.baseCliCmd, gitCmdArgs...)
+	execCmd.Dir = e.dir
+	// Don't wait forever for children keeping the output open, like ssh
+	execCmd.WaitDelay = time.Second
+	start := time.Now()
 	output, err := execCmd.CombinedOutput()
+	duration := time.Since(start)
 	result := strings.TrimSuffix(string(output), "\n")
+	exitCode := -1
+	if execCmd.ProcessState != nil {
+		exitCode = execCmd.ProcessState.ExitCode()
+	}
 
-	e.println("OUTPUT:\t", result)
-	if err != nil {
-		e.println("ERROR:\t", err, "\n")
-		return result, err
+	if err != nil && ctx.Err() != nil {
+		err = ErrInterrupted
+		result = strings.TrimPrefix(result+"\n"+commandLine+" interrupted", "\n")
+	} else if err != nil && timeoutCtx.Err() != nil {
+		err = ErrTimeout
+		result = strings.TrimPrefix(result+"\n"+commandLine+" timed out after "+timeout.String(), "\n")
 	}
-	e.println("")
 
-	return result, nil
+	e.logCommand(gitCmdArgs, duration, exitCode, result, err)
+	return result, err
+}
+
+// logCommand records a command at debug level, a failed one at info level
+// since some are expected to fail, and one that timed out or was interrupted at error level.
+// Credentials in URLs are redacted from the arguments and the output.
+func (e Executor) logCommand(args []string, duration time.Duration, exitCode int, output string, err error) {
+	level := slog.LevelDebug
+	if errors.Is(err, ErrTimeout) || errors.Is(err, ErrInterrupted) {
+		level = slog.LevelError
+	} else if err != nil {
+		level = slog.LevelInfo
+	}
+
+	redactedArgs := make([]string, len(args))
+	for i, arg := range args {
+		redactedArgs[i] = logger.Redact(arg)
+	}
+	attrs := []slog.Attr{
+		slog.String("cmd", e.baseCliCmd),
+		slog.Any("args", redactedArgs),
+		slog.Duration("duration", duration),
+		slog.Int("exitCode", exitCode),
+		slog.String("output", logger.Truncate(logger.Redact(output), logger.MaxOutputLength)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", logger.Redact(err.Error())))
+	}
+	e.log.LogAttrs(context.Background(), level, "exec", attrs...)
 }
@@ -0,0 +1,116 @@
+package cliexec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Interaction is a command run by an executor with its result.
+type Interaction struct {
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	Output   string   `json:"output"`
+	ExitCode int      `json:"exitCode"`
+}
+
+// Fixture is the content of a file written by a Recorder and served by a Replayer.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+func LoadFixture(path string) (Fixture, error) {
+	var fixture Fixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, err
+	}
+	err = json.Unmarshal(data, &fixture)
+	return fixture, err
+}
+
+func (f Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Recorder saves every command run through its executors to a fixture file.
+// The file is written after each command so nothing is lost when a command fails.
+type Recorder struct {
+	mutex   sync.Mutex
+	path    string
+	fixture Fixture
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Wrap returns an executor recording the commands run by executor as baseCliCmd.
+func (r *Recorder) Wrap(baseCliCmd string, executor InterfaceCliExecutor) RecordingExecutor {
+	return RecordingExecutor{
+		baseCliCmd: baseCliCmd,
+		executor:   executor,
+		recorder:   r,
+	}
+}
+
+func (r *Recorder) record(interaction Interaction) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	return r.fixture.Save(r.path)
+}
+
+type RecordingExecutor struct {
+	baseCliCmd string
+	executor   InterfaceCliExecutor
+	recorder   *Recorder
+}
+
+var _ InterfaceContextExecutor = RecordingExecutor{}
+
+func (e RecordingExecutor) Exec(command ...string) (string, error) {
+	output, err := e.executor.Exec(command...)
+	return e.recordResult(command, output, err)
+}
+
+// ExecContext is Exec bound to ctx when the wrapped executor can be cancelled.
+func (e RecordingExecutor) ExecContext(ctx context.Context, command ...string) (string, error) {
+	executor, ok := e.executor.(InterfaceContextExecutor)
+	if !ok {
+		return e.Exec(command...)
+	}
+	output, err := executor.ExecContext(ctx, command...)
+	return e.recordResult(command, output, err)
+}
+
+func (e RecordingExecutor) recordResult(command []string, output string, err error) (string, error) {
+	recordErr := e.recorder.record(Interaction{
+		Command:  e.baseCliCmd,
+		Args:     command,
+		Output:   output,
+		ExitCode: exitCode(err),
+	})
+	if recordErr != nil {
+		return output, errors.Join(err, recordErr)
+	}
+	return output, err
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && exitError.ExitCode() > 0 {
+		return exitError.ExitCode()
+	}
+	return 1
+}
@@ -0,0 +1,102 @@
+package cliexec
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type executorStub struct {
+	stubExec func(command ...string) (string, error)
+}
+
+func (e executorStub) Exec(command ...string) (string, error) {
+	return e.stubExec(command...)
+}
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	recorder := NewRecorder(path)
+	gitExecutor := recorder.Wrap("git", NewExecutor("git", nil))
+	ghExecutor := recorder.Wrap("gh", executorStub{
+		stubExec: func(command ...string) (string, error) {
+			return "no pull requests found", errors.New("exit status 1")
+		},
+	})
+
+	output, err := gitExecutor.Exec("--version")
+	if err != nil || !strings.HasPrefix(output, "git version") {
+		t.Fatalf("got %s %v, want git version", output, err)
+	}
+	_, err = gitExecutor.Exec("not-a-command")
+	if err == nil {
+		t.Errorf("got none, want Error")
+	}
+	_, err = ghExecutor.Exec("pr", "view")
+	if err == nil {
+		t.Errorf("got none, want Error")
+	}
+
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("show have no error, got %s", err)
+	}
+	want := []Interaction{
+		{Command: "git", Args: []string{"--version"}, Output: output, ExitCode: 0},
+		{Command: "git", Args: []string{"not-a-command"}, Output: fixture.Interactions[1].Output, ExitCode: 1},
+		{Command: "gh", Args: []string{"pr", "view"}, Output: "no pull requests found", ExitCode: 1},
+	}
+	if !reflect.DeepEqual(fixture.Interactions, want) {
+		t.Errorf("got %v, want %v", fixture.Interactions, want)
+	}
+	if !strings.Contains(fixture.Interactions[1].Output, "not a git command") {
+		t.Errorf("got \"%s\", want \"%s\"", fixture.Interactions[1].Output, "not a git command")
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	replayer := NewReplayer(Fixture{
+		Interactions: []Interaction{
+			{Command: "git", Args: []string{"fetch"}, Output: "", ExitCode: 0},
+			{Command: "git", Args: []string{"checkout", "branch1"}, Output: "error: pathspec", ExitCode: 1},
+			{Command: "git", Args: []string{"fetch"}, Output: "second fetch", ExitCode: 0},
+			{Command: "gh", Args: []string{"pr", "view"}, Output: "42", ExitCode: 0},
+		},
+	})
+	gitExecutor := replayer.Executor("git")
+
+	// Commands can be replayed out of order
+	output, err := gitExecutor.Exec("checkout", "branch1")
+	if err == nil || output != "error: pathspec" {
+		t.Errorf("got %s %v, want %s and an error", output, err, "error: pathspec")
+	}
+	_, _ = gitExecutor.Exec("fetch")
+	output, _ = gitExecutor.Exec("fetch")
+	if output != "second fetch" {
+		t.Errorf("got \"%s\", want \"%s\"", output, "second fetch")
+	}
+
+	if replayer.Err() != nil {
+		t.Errorf("show have no error, got %s", replayer.Err())
+	}
+
+	// Each interaction is served once and gh is not git
+	_, err = gitExecutor.Exec("fetch")
+	if err == nil {
+		t.Errorf("got none, want Error")
+	}
+	_, err = gitExecutor.Exec("pr", "view")
+	if err == nil {
+		t.Errorf("got none, want Error")
+	}
+
+	want := "unexpected commands:\ngit fetch\ngit pr view"
+	if replayer.Err() == nil || replayer.Err().Error() != want {
+		t.Errorf("got \"%v\", want \"%s\"", replayer.Err(), want)
+	}
+	if len(replayer.Remaining()) != 1 || replayer.Remaining()[0].Command != "gh" {
+		t.Errorf("got %v, want the gh interaction", replayer.Remaining())
+	}
+}
@@ -0,0 +1,78 @@
+package cliexec
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Replayer serves the interactions of a fixture instead of running commands.
+// Commands can be replayed in any order since some are run concurrently,
+// but each interaction is served only once.
+type Replayer struct {
+	mutex      sync.Mutex
+	remaining  []Interaction
+	unexpected []string
+}
+
+func NewReplayer(fixture Fixture) *Replayer {
+	return &Replayer{
+		remaining: slices.Clone(fixture.Interactions),
+	}
+}
+
+// Executor returns an executor replaying the interactions of baseCliCmd.
+func (r *Replayer) Executor(baseCliCmd string) ReplayExecutor {
+	return ReplayExecutor{
+		baseCliCmd: baseCliCmd,
+		replayer:   r,
+	}
+}
+
+func (r *Replayer) replay(baseCliCmd string, command []string) (Interaction, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, interaction := range r.remaining {
+		if interaction.Command == baseCliCmd && slices.Equal(interaction.Args, command) {
+			r.remaining = slices.Delete(r.remaining, i, i+1)
+			return interaction, true
+		}
+	}
+	r.unexpected = append(r.unexpected, baseCliCmd+" "+strings.Join(command, " "))
+	return Interaction{}, false
+}
+
+// Err reports the commands that were not in the fixture.
+func (r *Replayer) Err() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.unexpected) == 0 {
+		return nil
+	}
+	return errors.New("unexpected commands:\n" + strings.Join(r.unexpected, "\n"))
+}
+
+// Remaining are the interactions of the fixture that were not replayed.
+func (r *Replayer) Remaining() []Interaction {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Clone(r.remaining)
+}
+
+type ReplayExecutor struct {
+	baseCliCmd string
+	replayer   *Replayer
+}
+
+func (e ReplayExecutor) Exec(command ...string) (string, error) {
+	interaction, ok := e.replayer.replay(e.baseCliCmd, command)
+	if !ok {
+		return "", errors.New("unexpected command: " + e.baseCliCmd + " " + strings.Join(command, " "))
+	}
+	if interaction.ExitCode != 0 {
+		return interaction.Output, fmt.Errorf("exit status %d", interaction.ExitCode)
+	}
+	return interaction.Output, nil
+}
@@ -0,0 +1,57 @@
+package cliexec
+
+import (
+	"context"
+	"github.com/Bhacaz/gostacking/internal/logger"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// InterfaceCommandRunner runs a user command, like a build or a test suite, in dir.
+type InterfaceCommandRunner interface {
+	Run(dir string, env []string, command ...string) (string, error)
+}
+
+// CommandRunner has no timeout since user commands can run for a long time,
+// they are only killed when its context is done.
+type CommandRunner struct {
+	ctx context.Context
+	log *slog.Logger
+}
+
+func NewCommandRunner(ctx context.Context, log *slog.Logger) CommandRunner {
+	if log == nil {
+		log = logger.Discard()
+	}
+	return CommandRunner{ctx: ctx, log: log}
+}
+
+// Run adds env to the environment of the current process.
+func (r CommandRunner) Run(dir string, env []string, command ...string) (string, error) {
+	execCmd := exec.CommandContext(r.ctx, command[0], command[1:]...)
+	execCmd.Dir = dir
+	execCmd.Env = append(os.Environ(), env...)
+	execCmd.WaitDelay = time.Second
+
+	start := time.Now()
+	output, err := execCmd.CombinedOutput()
+	result := strings.TrimSuffix(string(output), "\n")
+	if err != nil && r.ctx.Err() != nil {
+		err = ErrInterrupted
+	}
+
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	r.log.LogAttrs(context.Background(), level, "run",
+		slog.String("dir", dir),
+		slog.Any("command", command),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("output", logger.Truncate(logger.Redact(result), logger.MaxOutputLength)),
+	)
+	return result, err
+}
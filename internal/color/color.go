@@ -1,6 +1,17 @@
 package color
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+const (
+	ModeAuto   = "auto"
+	ModeAlways = "always"
+	ModeNever  = "never"
+)
 
 var (
 	Black      = Color("\033[1;30m%s\033[0m")
@@ -14,8 +25,54 @@ var (
 	DarkYellow = Color("\033[38;5;3m%s\033[0m")
 )
 
+var mode = ModeAuto
+
+var ansiEscape = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// SetMode sets when colors are rendered:
+// auto (only on a terminal and without NO_COLOR), always or never.
+func SetMode(newMode string) error {
+	switch newMode {
+	case ModeAuto, ModeAlways, ModeNever:
+		mode = newMode
+		return nil
+	}
+	return errors.New("invalid color mode " + newMode + ", must be auto, always or never")
+}
+
+// Enabled is the central switch for colors, consulted by the color functions and the printer.
+func Enabled() bool {
+	switch mode {
+	case ModeAlways:
+		return true
+	case ModeNever:
+		return false
+	}
+	// https://no-color.org
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Strip removes the ANSI color escapes, e.g. the ones from a Git output.
+func Strip(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
 func Color(colorString string) func(...interface{}) string {
 	sprint := func(args ...interface{}) string {
+		if !Enabled() {
+			return fmt.Sprint(args...)
+		}
 		return fmt.Sprintf(colorString,
 			fmt.Sprint(args...))
 	}
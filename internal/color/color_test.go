@@ -0,0 +1,82 @@
+package color
+
+import (
+	"os"
+	"testing"
+)
+
+func withMode(t *testing.T, newMode string) {
+	t.Helper()
+	previousMode := mode
+	err := SetMode(newMode)
+	if err != nil {
+		t.Fatalf("show have no error, got %s", err)
+	}
+	t.Cleanup(func() { mode = previousMode })
+}
+
+func TestColor(t *testing.T) {
+	t.Run("when mode is never", func(t *testing.T) {
+		withMode(t, ModeNever)
+
+		got := Green("stack1")
+		if got != "stack1" {
+			t.Errorf("got %q, want %q", got, "stack1")
+		}
+	})
+
+	t.Run("when mode is always", func(t *testing.T) {
+		withMode(t, ModeAlways)
+		t.Setenv("NO_COLOR", "1")
+
+		got := Green("stack1")
+		want := "\033[1;32mstack1\033[0m"
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("when mode is auto and NO_COLOR is set", func(t *testing.T) {
+		withMode(t, ModeAuto)
+		t.Setenv("NO_COLOR", "1")
+
+		got := Yellow("branch1")
+		if got != "branch1" {
+			t.Errorf("got %q, want %q", got, "branch1")
+		}
+	})
+
+	t.Run("when mode is auto and stdout is not a terminal", func(t *testing.T) {
+		withMode(t, ModeAuto)
+		t.Setenv("NO_COLOR", "")
+
+		if isTerminal(os.Stdout) {
+			t.Skip("stdout is a terminal")
+		}
+
+		got := Red("*")
+		if got != "*" {
+			t.Errorf("got %q, want %q", got, "*")
+		}
+	})
+}
+
+func TestSetMode(t *testing.T) {
+	withMode(t, ModeAuto)
+
+	err := SetMode("sometimes")
+	if err == nil {
+		t.Errorf("got none, want Error")
+	}
+	if mode != ModeAuto {
+		t.Errorf("got %s, want %s", mode, ModeAuto)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	got := Strip("\033[1;32mstack1\033[0m - \033[38;5;3mabcdef\033[0m")
+	want := "stack1 - abcdef"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/cliexec"
+	"github.com/BurntSushi/toml"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config is read from these layers, each one overriding the previous:
+// 1. User file ~/.config/gostacking/config.toml
+// 2. Repository file .gostacking.toml
+// 3. Git config gostacking.*
+//
+// Keys are the dotted paths of the TOML files, e.g. sync.push,
+// and are prefixed by gostacking. in the git config.
+type Config struct {
+	// Remote is the remote of the stack branches and of the default branch
+	Remote string
+	// Color is when to use colors: auto, always or never
+	Color string
+	// Backend reads the repository with the git command (cli) or with go-git
+	Backend string
+	Sync    SyncConfig
+	// Forge maps a remote host to its forge, e.g. github.mycompany.com = github
+	Forge map[string]string
+}
+
+type SyncConfig struct {
+	// Strategy is how each branch is pulled: auto (git pull as configured), merge, rebase or ff-only
+	Strategy string
+	// Push is the default of sync --push
+	Push bool
+	// MergeDefault is the default of sync --merge-default
+	MergeDefault bool
+}
+
+const (
+	LayerUser = "user"
+	LayerRepo = "repo"
+	LayerGit  = "git"
+)
+
+const (
+	forgePrefix = "forge."
+	gitPrefix   = "gostacking."
+	repoFile    = ".gostacking.toml"
+)
+
+type key struct {
+	name         string
+	defaultValue string
+	values       []string
+}
+
+var booleans = []string{"true", "false"}
+
+var keys = []key{
+	{name: "remote", defaultValue: "origin"},
+	{name: "color", defaultValue: "auto", values: []string{"auto", "always", "never"}},
+	{name: "backend", defaultValue: "cli", values: []string{"cli", "go-git"}},
+	{name: "sync.strategy", defaultValue: "auto", values: []string{"auto", "merge", "rebase", "ff-only"}},
+	{name: "sync.push", defaultValue: "false", values: booleans},
+	{name: "sync.merge-default", defaultValue: "false", values: booleans},
+}
+
+var forges = []string{"github"}
+
+// Loader reads and writes the layers. UserFile is the user layer and
+// the git commands run in the repository of the repository layer.
+type Loader struct {
+	UserFile    string
+	GitExecutor cliexec.InterfaceCliExecutor
+}
+
+// DefaultUserFile is $XDG_CONFIG_HOME/gostacking/config.toml, ~/.config/gostacking/config.toml otherwise.
+func DefaultUserFile() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, _ := os.UserHomeDir()
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "gostacking", "config.toml")
+}
+
+// Entry is a value of the config with the layer it comes from, empty for a default.
+type Entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Layer string `json:"layer"`
+}
+
+// Load returns the defaults overridden by each layer.
+func (l Loader) Load() (Config, error) {
+	entries, err := l.List()
+	if err != nil {
+		return Config{}, err
+	}
+	return fromEntries(entries), nil
+}
+
+// Defaults is the config without any layer.
+func Defaults() Config {
+	var entries []Entry
+	for _, key := range keys {
+		entries = append(entries, Entry{Key: key.name, Value: key.defaultValue})
+	}
+	return fromEntries(entries)
+}
+
+func fromEntries(entries []Entry) Config {
+	config := Config{Forge: map[string]string{}}
+	for _, entry := range entries {
+		switch entry.Key {
+		case "remote":
+			config.Remote = entry.Value
+		case "color":
+			config.Color = entry.Value
+		case "backend":
+			config.Backend = entry.Value
+		case "sync.strategy":
+			config.Sync.Strategy = entry.Value
+		case "sync.push":
+			config.Sync.Push = entry.Value == "true"
+		case "sync.merge-default":
+			config.Sync.MergeDefault = entry.Value == "true"
+		default:
+			config.Forge[strings.TrimPrefix(entry.Key, forgePrefix)] = entry.Value
+		}
+	}
+	return config
+}
+
+// List returns all the keys with their value, sorted by key.
+func (l Loader) List() ([]Entry, error) {
+	values := map[string]Entry{}
+	for _, key := range keys {
+		values[key.name] = Entry{Key: key.name, Value: key.defaultValue}
+	}
+
+	for _, layer := range []string{LayerUser, LayerRepo, LayerGit} {
+		layerValues, err := l.read(layer)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range layerValues {
+			// Unknown keys are ignored, they can come from a newer version
+			if validateKey(name) != nil {
+				continue
+			}
+			err = validate(name, value)
+			if err != nil {
+				return nil, errors.New("invalid config in " + layer + " layer: " + err.Error())
+			}
+			values[name] = Entry{Key: name, Value: value, Layer: layer}
+		}
+	}
+
+	entries := make([]Entry, 0, len(values))
+	for _, entry := range values {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// Get returns the value of name after all the layers.
+func (l Loader) Get(name string) (Entry, error) {
+	err := validateKey(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	entries, err := l.List()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Key == name {
+			return entry, nil
+		}
+	}
+	return Entry{}, errors.New("no value for " + name)
+}
+
+// Set writes name in layer, the TOML files are created when missing.
+func (l Loader) Set(layer string, name string, value string) error {
+	err := validate(name, value)
+	if err != nil {
+		return err
+	}
+
+	if layer == LayerGit {
+		output, err := l.GitExecutor.Exec("config", gitPrefix+name, value)
+		if err != nil {
+			return errors.New("failed to set " + name + " in git config\n" + output)
+		}
+		return nil
+	}
+
+	path, err := l.path(layer)
+	if err != nil {
+		return err
+	}
+	content, err := readToml(path)
+	if err != nil {
+		return err
+	}
+
+	var typedValue interface{} = value
+	if slices.Contains(booleans, value) && !strings.HasPrefix(name, forgePrefix) {
+		typedValue, _ = strconv.ParseBool(value)
+	}
+	table, leaf := content, name
+	if section, rest, nested := strings.Cut(name, "."); nested {
+		sub, ok := content[section].(map[string]interface{})
+		if !ok {
+			sub = map[string]interface{}{}
+			content[section] = sub
+		}
+		table, leaf = sub, rest
+	}
+	table[leaf] = typedValue
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return toml.NewEncoder(file).Encode(content)
+}
+
+func (l Loader) path(layer string) (string, error) {
+	switch layer {
+	case LayerUser:
+		return l.UserFile, nil
+	case LayerRepo:
+		toplevel, err := l.GitExecutor.Exec("rev-parse", "--show-toplevel")
+		if err != nil {
+			return "", errors.New("failed to find the repository\n" + toplevel)
+		}
+		return filepath.Join(toplevel, repoFile), nil
+	}
+	return "", errors.New("invalid layer " + layer + ", must be user, repo or git")
+}
+
+// read returns the flattened keys of a layer, nothing for a missing file or outside a repository.
+func (l Loader) read(layer string) (map[string]string, error) {
+	if layer == LayerGit {
+		return l.readGit(), nil
+	}
+
+	path, err := l.path(layer)
+	if err != nil {
+		return map[string]string{}, nil
+	}
+	content, err := readToml(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for name, value := range content {
+		if table, ok := value.(map[string]interface{}); ok {
+			for subName, subValue := range table {
+				values[name+"."+subName] = fmt.Sprint(subValue)
+			}
+			continue
+		}
+		values[name] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+func (l Loader) readGit() map[string]string {
+	values := map[string]string{}
+	output, err := l.GitExecutor.Exec("config", "--get-regexp", `^gostacking\.`)
+	if err != nil {
+		return values
+	}
+	for _, line := range strings.Split(output, "\n") {
+		name, value, _ := strings.Cut(strings.TrimPrefix(line, gitPrefix), " ")
+		values[name] = value
+	}
+	return values
+}
+
+func readToml(path string) (map[string]interface{}, error) {
+	content := map[string]interface{}{}
+	_, err := toml.DecodeFile(path, &content)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("failed to read " + path + "\n" + err.Error())
+	}
+	return content, nil
+}
+
+func validateKey(name string) error {
+	if strings.HasPrefix(name, forgePrefix) && len(name) > len(forgePrefix) {
+		return nil
+	}
+	for _, key := range keys {
+		if key.name == name {
+			return nil
+		}
+	}
+	return errors.New("unknown config " + name)
+}
+
+func validate(name string, value string) error {
+	err := validateKey(name)
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	if strings.HasPrefix(name, forgePrefix) {
+		values = forges
+	}
+	for _, key := range keys {
+		if key.name == name {
+			values = key.values
+		}
+	}
+	if value == "" {
+		return errors.New("invalid empty value for " + name)
+	}
+	if values != nil && !slices.Contains(values, value) {
+		return errors.New("invalid value \"" + value + "\" for " + name + ", must be " + strings.Join(values, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type gitExecutorStub struct {
+	toplevel  string
+	gitConfig map[string]string
+}
+
+// Exec answers rev-parse --show-toplevel and the config commands from gitConfig.
+func (g gitExecutorStub) Exec(command ...string) (string, error) {
+	switch {
+	case strings.Join(command, " ") == "rev-parse --show-toplevel":
+		return g.toplevel, nil
+	case command[0] == "config" && command[1] == "--get-regexp":
+		var lines []string
+		for key, value := range g.gitConfig {
+			lines = append(lines, key+" "+value)
+		}
+		if len(lines) == 0 {
+			return "", errors.New("exit status 1")
+		}
+		return strings.Join(lines, "\n"), nil
+	case command[0] == "config":
+		g.gitConfig[command[1]] = command[2]
+		return "", nil
+	}
+	return "", errors.New("unexpected command")
+}
+
+func newTestLoader(t *testing.T, gitConfig map[string]string) Loader {
+	dir := t.TempDir()
+	return Loader{
+		UserFile: filepath.Join(dir, "user", "config.toml"),
+		GitExecutor: gitExecutorStub{
+			toplevel:  filepath.Join(dir, "repo"),
+			gitConfig: gitConfig,
+		},
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_Load(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		loader := newTestLoader(t, map[string]string{})
+
+		config, err := loader.Load()
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		want := Config{
+			Remote:  "origin",
+			Color:   "auto",
+			Backend: "cli",
+			Sync:    SyncConfig{Strategy: "auto"},
+			Forge:   map[string]string{},
+		}
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("got %v, want %v", config, want)
+		}
+	})
+
+	t.Run("layers", func(t *testing.T) {
+		loader := newTestLoader(t, map[string]string{
+			"gostacking.sync.push":     "false",
+			"gostacking.hook.pre-sync": "make lint",
+		})
+		writeFile(t, loader.UserFile, `
+remote = "upstream"
+color = "never"
+
+[sync]
+push = true
+merge-default = true
+`)
+		writeFile(t, filepath.Join(loader.GitExecutor.(gitExecutorStub).toplevel, ".gostacking.toml"), `
+remote = "company"
+unknown = "from a newer version"
+
+[sync]
+strategy = "rebase"
+
+[forge]
+"github.mycompany.com" = "github"
+`)
+
+		config, err := loader.Load()
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		want := Config{
+			Remote:  "company",
+			Color:   "never",
+			Backend: "cli",
+			Sync:    SyncConfig{Strategy: "rebase", Push: false, MergeDefault: true},
+			Forge:   map[string]string{"github.mycompany.com": "github"},
+		}
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("got %v, want %v", config, want)
+		}
+
+		entry, _ := loader.Get("sync.push")
+		if entry != (Entry{Key: "sync.push", Value: "false", Layer: LayerGit}) {
+			t.Errorf("got %v, want sync.push from git", entry)
+		}
+	})
+
+	t.Run("when a value is invalid", func(t *testing.T) {
+		loader := newTestLoader(t, map[string]string{})
+		writeFile(t, loader.UserFile, `color = "sometimes"`)
+
+		_, err := loader.Load()
+
+		want := "invalid config in user layer: invalid value \"sometimes\" for color"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %s", err, want)
+		}
+	})
+
+	t.Run("when a file is not TOML", func(t *testing.T) {
+		loader := newTestLoader(t, map[string]string{})
+		writeFile(t, loader.UserFile, `color: never`)
+
+		_, err := loader.Load()
+
+		if err == nil || !strings.Contains(err.Error(), "failed to read "+loader.UserFile) {
+			t.Errorf("got %v, want failed to read", err)
+		}
+	})
+}
+
+func TestLoader_Set(t *testing.T) {
+	gitConfig := map[string]string{}
+	loader := newTestLoader(t, gitConfig)
+
+	for _, set := range []struct{ layer, key, value string }{
+		{LayerUser, "sync.push", "true"},
+		{LayerUser, "remote", "upstream"},
+		{LayerRepo, "forge.github.mycompany.com", "github"},
+		{LayerRepo, "sync.strategy", "ff-only"},
+		{LayerGit, "backend", "go-git"},
+	} {
+		err := loader.Set(set.layer, set.key, set.value)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+	}
+
+	content, _ := os.ReadFile(loader.UserFile)
+	want := "remote = \"upstream\"\n\n[sync]\n  push = true\n"
+	if string(content) != want {
+		t.Errorf("got \"%s\", want \"%s\"", content, want)
+	}
+	if gitConfig["gostacking.backend"] != "go-git" {
+		t.Errorf("got %v, want gostacking.backend in git config", gitConfig)
+	}
+
+	config, _ := loader.Load()
+	if !config.Sync.Push || config.Sync.Strategy != "ff-only" || config.Forge["github.mycompany.com"] != "github" {
+		t.Errorf("got %v, want the values set", config)
+	}
+
+	for _, set := range []struct{ layer, key, value string }{
+		{LayerUser, "sync.push", "yes"},
+		{LayerUser, "unknown", "value"},
+		{LayerUser, "forge.gitlab.com", "gitlab"},
+		{LayerUser, "remote", ""},
+		{"global", "remote", "upstream"},
+	} {
+		err := loader.Set(set.layer, set.key, set.value)
+		if err == nil {
+			t.Errorf("%s %s=%s: got none, want Error", set.layer, set.key, set.value)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	got := Defaults()
+
+	if got.Remote != "origin" || got.Color != "auto" || got.Backend != "cli" || got.Sync.Strategy != "auto" || got.Sync.Push {
+		t.Errorf("got %v, want the default of each key", got)
+	}
+}
@@ -0,0 +1,42 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Editor interface {
+	Edit(path string) error
+}
+
+type editor struct{}
+
+func NewEditor() Editor {
+	return editor{}
+}
+
+// Edit opens the file in the user editor and waits for it to be closed.
+// The editor is resolved like Git does (GIT_EDITOR, core.editor, VISUAL, EDITOR).
+func (e editor) Edit(path string) error {
+	// The editor can contain arguments (e.g. "code --wait"), let the shell split them.
+	cmd := exec.Command("sh", "-c", editorCommand()+` "$@"`, "gostacking-editor", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func editorCommand() string {
+	output, err := exec.Command("git", "var", "GIT_EDITOR").Output()
+	if err == nil && len(strings.TrimSpace(string(output))) > 0 {
+		return strings.TrimSpace(string(output))
+	}
+	if visual := os.Getenv("VISUAL"); visual != "" {
+		return visual
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	return "vi"
+}
@@ -0,0 +1,52 @@
+package pager
+
+import (
+	"fmt"
+	"golang.org/x/term"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Pager interface {
+	Page(content string) error
+}
+
+type pager struct{}
+
+func NewPager() Pager {
+	return pager{}
+}
+
+// Page shows the content in the user pager when the output is a terminal, or prints it.
+// The pager is resolved like Git does (GIT_PAGER, core.pager, PAGER, less).
+func (p pager) Page(content string) error {
+	command := pagerCommand()
+	if !term.IsTerminal(int(os.Stdout.Fd())) || command == "" || command == "cat" {
+		_, err := fmt.Fprintln(os.Stdout, content)
+		return err
+	}
+
+	// The pager can contain arguments (e.g. "less -S"), let the shell split them.
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = strings.NewReader(content + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Like Git: quit when it fits the screen and keep the colors
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	return cmd.Run()
+}
+
+func pagerCommand() string {
+	output, err := exec.Command("git", "var", "GIT_PAGER").Output()
+	if err == nil {
+		return strings.TrimSpace(string(output))
+	}
+	if pager := os.Getenv("PAGER"); pager != "" {
+		return pager
+	}
+	return "less"
+}
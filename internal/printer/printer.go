@@ -1,17 +1,39 @@
 package printer
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"io"
+	"os"
+)
 
 type Printer interface {
 	Println(a ...interface{})
 }
 
-type printer struct{}
+type printer struct {
+	out io.Writer
+}
 
 func NewPrinter() Printer {
-	return printer{}
+	return printer{out: os.Stdout}
+}
+
+func NewPrinterTo(out io.Writer) Printer {
+	return printer{out: out}
 }
 
+// Println prints like fmt.Println. When colors are disabled,
+// the ANSI escapes are removed, including the ones coming from Git outputs.
 func (p printer) Println(a ...interface{}) {
-	fmt.Println(a...)
+	if color.Enabled() {
+		fmt.Fprintln(p.out, a...)
+		return
+	}
+
+	plain := make([]interface{}, len(a))
+	for i, v := range a {
+		plain[i] = color.Strip(fmt.Sprint(v))
+	}
+	fmt.Fprintln(p.out, plain...)
 }
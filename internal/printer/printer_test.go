@@ -0,0 +1,37 @@
+package printer
+
+import (
+	"bytes"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"testing"
+)
+
+func TestPrinter_Println(t *testing.T) {
+	t.Run("when colors are disabled", func(t *testing.T) {
+		t.Cleanup(func() { _ = color.SetMode(color.ModeAuto) })
+		_ = color.SetMode(color.ModeNever)
+
+		var out bytes.Buffer
+		p := printer{out: &out}
+		p.Println("Current stack:", color.Green("stack1"), "\033[31mfrom git\033[m")
+
+		want := "Current stack: stack1 from git\n"
+		if out.String() != want {
+			t.Errorf("got %q, want %q", out.String(), want)
+		}
+	})
+
+	t.Run("when colors are enabled", func(t *testing.T) {
+		t.Cleanup(func() { _ = color.SetMode(color.ModeAuto) })
+		_ = color.SetMode(color.ModeAlways)
+
+		var out bytes.Buffer
+		p := printer{out: &out}
+		p.Println("Current stack:", color.Green("stack1"))
+
+		want := "Current stack: \033[1;32mstack1\033[0m\n"
+		if out.String() != want {
+			t.Errorf("got %q, want %q", out.String(), want)
+		}
+	})
+}
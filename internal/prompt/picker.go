@@ -0,0 +1,140 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/terminal"
+	"io"
+	"sort"
+	"strings"
+)
+
+var ErrCancelled = errors.New("cancelled")
+
+// Pick shows the items in a full screen list filtered while typing, like fzf,
+// and returns the one selected with enter. Escape or Ctrl-C returns ErrCancelled.
+func Pick(term terminal.Terminal, title string, items []string) (string, error) {
+	if len(items) == 0 {
+		return "", errors.New("nothing to pick")
+	}
+
+	query := ""
+	selected := 0
+	matches := items
+	for {
+		renderPicker(term, title, query, matches, selected, len(items))
+		key, err := term.ReadKey()
+		if errors.Is(err, io.EOF) {
+			return "", ErrCancelled
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case terminal.KeyEscape, terminal.KeyCtrlC:
+			return "", ErrCancelled
+		case terminal.KeyEnter:
+			if len(matches) > 0 {
+				return matches[selected], nil
+			}
+		case terminal.KeyUp:
+			selected = max(selected-1, 0)
+		case terminal.KeyDown:
+			selected = min(selected+1, max(len(matches)-1, 0))
+		case terminal.KeyBackspace:
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				matches = FuzzyFilter(query, items)
+				selected = 0
+			}
+		case terminal.KeyLeft, terminal.KeyRight, terminal.KeyTab, "":
+		default:
+			query += string(key)
+			matches = FuzzyFilter(query, items)
+			selected = 0
+		}
+	}
+}
+
+func renderPicker(term terminal.Terminal, title string, query string, matches []string, selected int, total int) {
+	_, height := term.Size()
+	listHeight := max(height-3, 1)
+	start := max(0, selected-listHeight+1)
+
+	lines := []string{
+		title + " " + color.DarkYellow("(type to filter, ↑↓ to select, enter to confirm, esc to cancel)"),
+		"> " + query,
+	}
+	for i := start; i < min(len(matches), start+listHeight); i++ {
+		if i == selected {
+			lines = append(lines, color.Teal("▸ "+matches[i]))
+		} else {
+			lines = append(lines, "  "+matches[i])
+		}
+	}
+	lines = append(lines, color.DarkYellow(fmt.Sprintf("%d/%d", len(matches), total)))
+	_, _ = io.WriteString(term, terminal.ClearScreen+strings.Join(lines, "\r\n"))
+}
+
+// FuzzyFilter returns the items containing the characters of query in order, ignoring the case.
+// The closest matches come first: the shortest span of the characters, then the earliest.
+func FuzzyFilter(query string, items []string) []string {
+	type match struct {
+		item  string
+		span  int
+		start int
+	}
+	var matches []match
+	for _, item := range items {
+		start, span, found := fuzzyMatch(query, item)
+		if found {
+			matches = append(matches, match{item: item, span: span, start: start})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].span != matches[j].span {
+			return matches[i].span < matches[j].span
+		}
+		return matches[i].start < matches[j].start
+	})
+
+	filtered := make([]string, 0, len(matches))
+	for _, match := range matches {
+		filtered = append(filtered, match.item)
+	}
+	return filtered
+}
+
+// fuzzyMatch finds the shortest span of item containing the characters of query in order.
+func fuzzyMatch(query string, item string) (int, int, bool) {
+	queryRunes := []rune(strings.ToLower(query))
+	itemRunes := []rune(strings.ToLower(item))
+	if len(queryRunes) == 0 {
+		return 0, 0, true
+	}
+
+	bestStart, bestSpan := -1, 0
+	for start := range itemRunes {
+		if itemRunes[start] != queryRunes[0] {
+			continue
+		}
+		matched := 0
+		for i := start; i < len(itemRunes); i++ {
+			if itemRunes[i] != queryRunes[matched] {
+				continue
+			}
+			matched++
+			if matched == len(queryRunes) {
+				span := i - start + 1
+				if bestStart == -1 || span < bestSpan {
+					bestStart, bestSpan = start, span
+				}
+				break
+			}
+		}
+	}
+	return bestStart, bestSpan, bestStart != -1
+}
@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/terminal"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPick(t *testing.T) {
+	t.Cleanup(func() { _ = color.SetMode(color.ModeAuto) })
+	_ = color.SetMode(color.ModeNever)
+	items := []string{"feature/login", "feature/logout", "fix/typo"}
+
+	t.Run("enter picks the selected item", func(t *testing.T) {
+		term := terminal.NewFake(80, 24, terminal.KeyDown, terminal.KeyEnter)
+
+		got, err := Pick(term, "Branch", items)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if got != "feature/logout" {
+			t.Errorf("got \"%s\", want \"%s\"", got, "feature/logout")
+		}
+		if !strings.Contains(term.Screens()[1], "▸ feature/logout") {
+			t.Errorf("got \"%s\", want \"%s\"", term.Screens()[1], "▸ feature/logout")
+		}
+	})
+
+	t.Run("typing filters the items", func(t *testing.T) {
+		term := terminal.NewFake(80, 24, "f", "t", "y", terminal.KeyBackspace, "p", terminal.KeyEnter)
+
+		got, err := Pick(term, "Branch", items)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if got != "fix/typo" {
+			t.Errorf("got \"%s\", want \"%s\"", got, "fix/typo")
+		}
+		if !strings.Contains(term.Screen(), "> ftp\n▸ fix/typo\n1/3") {
+			t.Errorf("got \"%s\", want \"%s\"", term.Screen(), "> ftp\n▸ fix/typo\n1/3")
+		}
+	})
+
+	t.Run("enter without match does nothing", func(t *testing.T) {
+		term := terminal.NewFake(80, 24, "z", terminal.KeyEnter, terminal.KeyBackspace, terminal.KeyEnter)
+
+		got, err := Pick(term, "Branch", items)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if got != "feature/login" {
+			t.Errorf("got \"%s\", want \"%s\"", got, "feature/login")
+		}
+	})
+
+	t.Run("escape cancels", func(t *testing.T) {
+		term := terminal.NewFake(80, 24, terminal.KeyEscape)
+
+		_, err := Pick(term, "Branch", items)
+
+		if !errors.Is(err, ErrCancelled) {
+			t.Errorf("got %v, want %v", err, ErrCancelled)
+		}
+	})
+
+	t.Run("without items", func(t *testing.T) {
+		_, err := Pick(terminal.NewFake(80, 24), "Branch", nil)
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	items := []string{"feature/login", "fix/login", "feature/logout", "main"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", items},
+		{"login", []string{"fix/login", "feature/login"}},
+		{"FLOG", []string{"fix/login", "feature/login", "feature/logout"}},
+		{"out", []string{"feature/logout"}},
+		{"xyz", []string{}},
+	}
+	for _, test := range tests {
+		got := FuzzyFilter(test.query, items)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.query, got, test.want)
+		}
+	}
+}
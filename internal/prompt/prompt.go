@@ -0,0 +1,38 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Prompter interface {
+	Confirm(question string) bool
+}
+
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func NewPrompter() Prompter {
+	return prompter{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stdout,
+	}
+}
+
+// Confirm asks a yes/no question and returns true only on an explicit yes.
+// If nothing can be read (e.g. stdin is closed), the answer is no.
+func (p prompter) Confirm(question string) bool {
+	fmt.Fprint(p.out, question+" [y/N] ")
+	answer, err := p.in.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(p.out)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -1,12 +1,23 @@
 package stack
 
-import "github.com/Bhacaz/gostacking/internal/color"
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"strings"
+)
 
 type branchStatus struct {
-	BehindRemote        bool
-	AheadRemote         bool
-	HasDiff             bool
-	BehindDefaultBranch bool
+	BehindRemote        bool `json:"behindRemote"`
+	AheadRemote         bool `json:"aheadRemote"`
+	HasDiff             bool `json:"hasDiff"`
+	BehindDefaultBranch bool `json:"behindDefaultBranch"`
+	// Number of commits behind and ahead the remote branch
+	BehindRemoteCount int `json:"behindRemoteCount"`
+	AheadRemoteCount  int `json:"aheadRemoteCount"`
+	// Number of commits in the parent branch not merged yet
+	BehindParentCount int `json:"behindParentCount"`
+	// Number of commits in the default branch not merged yet (first branch only)
+	BehindDefaultBranchCount int `json:"behindDefaultBranchCount"`
 }
 
 func defaultBranchStatus() branchStatus {
@@ -18,22 +29,42 @@ func defaultBranchStatus() branchStatus {
 	}
 }
 
+func (bs *branchStatus) setRemote(ahead int, behind int) {
+	bs.AheadRemoteCount = ahead
+	bs.AheadRemote = ahead > 0
+	bs.BehindRemoteCount = behind
+	bs.BehindRemote = behind > 0
+}
+
+func (bs *branchStatus) setBehindParent(behind int) {
+	bs.BehindParentCount = behind
+	bs.HasDiff = behind > 0
+}
+
+func (bs *branchStatus) setBehindDefaultBranch(behind int) {
+	bs.BehindDefaultBranchCount = behind
+	bs.BehindDefaultBranch = behind > 0
+}
+
+// Symbols shows the number of commits:
+// ↓ behind the remote, ↑ ahead of the remote,
+// ⇣ behind the parent branch (red) or the default branch (magenta)
 func (bs branchStatus) Symbols() string {
-	var symbolsToDisplay string
+	var symbols []string
 	if bs.BehindRemote {
-		symbolsToDisplay += color.Teal("↓")
+		symbols = append(symbols, color.Teal(fmt.Sprintf("↓%d", bs.BehindRemoteCount)))
 	}
 	if bs.AheadRemote {
-		symbolsToDisplay += color.Teal("↑")
+		symbols = append(symbols, color.Teal(fmt.Sprintf("↑%d", bs.AheadRemoteCount)))
 	}
 	if bs.HasDiff {
-		symbolsToDisplay += color.Red("*")
+		symbols = append(symbols, color.Red(fmt.Sprintf("⇣%d", bs.BehindParentCount)))
 	}
 	if bs.BehindDefaultBranch {
-		symbolsToDisplay += color.Magenta("*")
+		symbols = append(symbols, color.Magenta(fmt.Sprintf("⇣%d", bs.BehindDefaultBranchCount)))
 	}
-	if symbolsToDisplay != "" {
-		return " " + symbolsToDisplay
+	if len(symbols) > 0 {
+		return " " + strings.Join(symbols, " ")
 	}
-	return symbolsToDisplay
+	return ""
 }
@@ -0,0 +1,57 @@
+package stack
+
+import (
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/config"
+)
+
+func (sm StacksManager) configLoader() config.Loader {
+	return config.Loader{
+		UserFile:    sm.configFile,
+		GitExecutor: sm.gitExecutor,
+	}
+}
+
+func (sm StacksManager) ConfigGet(key string) error {
+	entry, err := sm.configLoader().Get(key)
+	if err != nil {
+		return err
+	}
+	if sm.output == OutputJSON {
+		return sm.printJSON(entry)
+	}
+	sm.printer.Println(entry.Value)
+	return nil
+}
+
+// ConfigSet writes the key in layer: user, repo or git.
+func (sm StacksManager) ConfigSet(layer string, key string, value string) error {
+	err := sm.configLoader().Set(layer, key, value)
+	if err != nil {
+		return err
+	}
+	sm.printer.Println("Set", color.Magenta(key), "to", value, "in", layer, "config")
+	return nil
+}
+
+// ConfigList shows every key with its value and the layer it comes from.
+func (sm StacksManager) ConfigList() error {
+	entries, err := sm.configLoader().List()
+	if err != nil {
+		return err
+	}
+	if sm.output == OutputJSON {
+		return sm.printJSON(entries)
+	}
+
+	output := ""
+	for _, entry := range entries {
+		layer := entry.Layer
+		if layer == "" {
+			layer = "default"
+		}
+		output += color.Magenta(entry.Key) + "=" + entry.Value + " " + color.DarkYellow("("+layer+")") + "\n"
+	}
+	sm.printer.Println(output)
+	return nil
+}
@@ -0,0 +1,190 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type DiffOptions struct {
+	// Branch is the name or the number of a branch of the current stack, the current branch when empty
+	Branch string
+	// Stat only shows the files changed, insertions and deletions
+	Stat bool
+	// NameOnly only shows the names of the files changed
+	NameOnly bool
+	// NoPager prints the diff instead of showing it in the pager
+	NoPager bool
+	// Since is a date like "yesterday" or "2024-01-01 10:00", or the number of a reflog entry like "1",
+	// to compare each branch with its version at that time instead of its parent
+	Since string
+}
+
+var shaRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Diff shows the changes of a branch of the current stack since its parent in the stack,
+// or the default branch for the first one, like `git diff parent...branch`.
+// With options.Since, it shows what changed in each branch since an older version instead.
+func (sm StacksManager) Diff(options DiffOptions) error {
+	if options.Since != "" {
+		if options.Branch != "" || options.Stat || options.NameOnly {
+			return errors.New("--since compares every branch of the stack, it can't be used with a branch, --stat or --name-only")
+		}
+		return sm.diffSince(options.Since)
+	}
+
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	branches, err := data.GetBranchesByName(data.CurrentStack)
+	if err != nil {
+		return err
+	}
+	i, err := sm.stackBranchIndex(branches, options.Branch)
+	if err != nil {
+		return err
+	}
+	parent, err := sm.stackParent(branches, i)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"diff"}
+	if color.Enabled() {
+		args = append(args, "--color")
+	}
+	if options.Stat {
+		args = append(args, "--stat")
+	}
+	if options.NameOnly {
+		args = append(args, "--name-only")
+	}
+	// From the merge base, the changes of the parent not merged yet are not part of the branch
+	args = append(args, parent+"..."+branches[i])
+	output, err := sm.gitExecutor.Exec(args...)
+	if err != nil {
+		return errors.New("failed to diff " + branches[i] + "\n" + output)
+	}
+	if output == "" {
+		sm.printer.Println("No changes in " + color.Yellow(branches[i]) + " since " + color.Yellow(parent))
+		return nil
+	}
+	if sm.pager != nil && !options.NoPager {
+		return sm.pager.Page(output)
+	}
+	sm.printer.Println(output)
+	return nil
+}
+
+// stackBranchIndex finds a branch of the stack by its name or number, the current branch when empty.
+func (sm StacksManager) stackBranchIndex(branches []string, branch string) (int, error) {
+	if branch == "" {
+		currentBranch, err := sm.currentBranchName()
+		if err != nil {
+			return 0, err
+		}
+		branch = currentBranch
+	} else if n, err := strconv.Atoi(branch); err == nil {
+		if n < 1 || n > len(branches) {
+			return 0, errors.New("invalid branch number")
+		}
+		return n - 1, nil
+	}
+
+	i := slices.Index(branches, branch)
+	if i == -1 {
+		return 0, errors.New("branch " + color.Yellow(branch) + " is not part of the current stack " + color.Green(sm.stacks.CurrentStack))
+	}
+	return i, nil
+}
+
+// stackParent is the previous branch in the stack, or the default branch with its remote for the first one.
+func (sm StacksManager) stackParent(branches []string, i int) (string, error) {
+	if i == 0 {
+		return sm.defaultBranchWithRemote()
+	}
+	return branches[i-1], nil
+}
+
+// diffSince shows what changed in each branch of the current stack since an older version,
+// like `git range-diff` between the commits of the old and the new branch.
+// The old versions come from the reflog. The merges and the commits of the parent
+// are not compared, only the commits of the branch itself.
+func (sm StacksManager) diffSince(since string) error {
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	branches, err := data.GetBranchesByName(data.CurrentStack)
+	if err != nil {
+		return err
+	}
+
+	for i, branch := range branches {
+		parent, err := sm.stackParent(branches, i)
+		if err != nil {
+			return err
+		}
+		output, err := sm.rangeDiffSince(parent, branch, since)
+		if err != nil {
+			return err
+		}
+		sm.printer.Println(color.Yellow(branch))
+		sm.printer.Println(output)
+	}
+	return nil
+}
+
+// rangeDiffSince compares the commits of branch not in parent, with the ones at the time of since.
+func (sm StacksManager) rangeDiffSince(parent string, branch string, since string) (string, error) {
+	oldTip := sm.reflogSha(branch, since)
+	if oldTip == "" {
+		return "  No version of the branch in the reflog at " + since, nil
+	}
+	if oldTip == sm.commitSha(branch) {
+		return "  Unchanged", nil
+	}
+	// The parent may have been rewritten too, its commits are not part of the old branch
+	oldParent := sm.reflogSha(parent, since)
+	if oldParent == "" {
+		oldParent = parent
+	}
+
+	// git range-diff needs commits in both ranges
+	oldCount, err := sm.commitsCount(oldTip, oldParent)
+	if err != nil {
+		return "", err
+	}
+	newCount, err := sm.commitsCount(branch, parent)
+	if err != nil {
+		return "", err
+	}
+	if oldCount == 0 || newCount == 0 {
+		return "  " + plural(oldCount, "commit") + " at " + since + ", " + plural(newCount, "commit") + " now", nil
+	}
+
+	colorFlag := "--no-color"
+	if color.Enabled() {
+		colorFlag = "--color"
+	}
+	output, err := sm.gitExecutor.Exec("range-diff", colorFlag, oldParent+".."+oldTip, parent+".."+branch)
+	if err != nil {
+		return "", errors.New("failed to compare the versions of " + branch + "\n" + output)
+	}
+	return "  " + strings.ReplaceAll(output, "\n", "\n  "), nil
+}
+
+// reflogSha is the commit of ref at a date or a reflog entry, empty if the reflog doesn't have it.
+func (sm StacksManager) reflogSha(ref string, since string) string {
+	output, err := sm.gitExecutor.Exec("rev-parse", "--verify", "--quiet", ref+"@{"+since+"}")
+	if err != nil {
+		return ""
+	}
+	// Git warns when the reflog doesn't go back to the date, the commit is the last line
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	sha := strings.TrimSpace(lines[len(lines)-1])
+	if !shaRegexp.MatchString(sha) {
+		return ""
+	}
+	return sha
+}
@@ -0,0 +1,183 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStacksManager_Diff_Since(t *testing.T) {
+	t.Cleanup(func() { _ = color.SetMode(color.ModeAuto) })
+	_ = color.SetMode(color.ModeNever)
+
+	oldBranch1 := strings.Repeat("1", 40)
+	newBranch1 := strings.Repeat("a", 40)
+	branch2 := strings.Repeat("2", 40)
+	var gitCommands []string
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			gitCommands = append(gitCommands, strings.Join(command, " "))
+			switch strings.Join(command, " ") {
+			case "symbolic-ref refs/remotes/origin/HEAD --short":
+				return "origin/main", nil
+			case "rev-parse --verify --quiet branch1@{yesterday}":
+				return "warning: log for 'branch1' only goes back to Mon, 1 Jan 2024\n" + oldBranch1, nil
+			case "rev-parse branch1":
+				return newBranch1, nil
+			case "rev-parse --verify --quiet branch2@{yesterday}", "rev-parse branch2":
+				return branch2, nil
+			case "rev-parse --verify --quiet origin/main@{yesterday}":
+				return "", errors.New("exit status 1")
+			case "rev-list --count --no-merges " + oldBranch1 + " ^origin/main", "rev-list --count --no-merges branch1 ^origin/main":
+				return "1", nil
+			case "range-diff --no-color origin/main.." + oldBranch1 + " origin/main..branch1":
+				return "1:  abc1234 ! 1:  def5678 Add feature", nil
+			}
+			return "", nil
+		},
+	}
+	var messageReceived []string
+	stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+	err := stacksManager.Diff(DiffOptions{Since: "yesterday"})
+
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+	want := "branch1\n  1:  abc1234 ! 1:  def5678 Add feature\nbranch2\n  Unchanged\n"
+	if stacksManager.printerMessage() != want {
+		t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+	}
+	rangeDiffs := slices.DeleteFunc(gitCommands, func(command string) bool { return !strings.HasPrefix(command, "range-diff") })
+	if len(rangeDiffs) != 1 {
+		t.Errorf("got %v, want no range-diff for branch2", rangeDiffs)
+	}
+}
+
+type pagerStub struct {
+	content *string
+}
+
+func (p pagerStub) Page(content string) error {
+	*p.content = content
+	return nil
+}
+
+func TestStacksManager_Diff(t *testing.T) {
+	diffStacksManager := func(gitCommands *[]string) StacksManager {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				*gitCommands = append(*gitCommands, strings.Join(command, " "))
+				switch command[0] {
+				case "rev-parse":
+					return "branch1", nil
+				case "symbolic-ref":
+					return "origin/main", nil
+				case "diff":
+					if command[len(command)-1] == "branch3...branch4" {
+						return "", nil
+					}
+					return " file.go | 2 +-", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		return StacksManagerForTest(gitExecutor, &messageReceived)
+	}
+
+	t.Run("the current branch against the default branch", func(t *testing.T) {
+		var gitCommands []string
+		stacksManager := diffStacksManager(&gitCommands)
+
+		err := stacksManager.Diff(DiffOptions{})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if !slices.Contains(gitCommands, "diff origin/main...branch1") {
+			t.Errorf("got %v, want %s", gitCommands, "diff origin/main...branch1")
+		}
+		if stacksManager.printerMessage() != " file.go | 2 +-\n" {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), " file.go | 2 +-\n")
+		}
+	})
+
+	t.Run("a branch by number against its parent in the pager", func(t *testing.T) {
+		var gitCommands []string
+		stacksManager := diffStacksManager(&gitCommands)
+		var paged string
+		stacksManager.pager = pagerStub{content: &paged}
+
+		err := stacksManager.Diff(DiffOptions{Branch: "2", Stat: true})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if !slices.Contains(gitCommands, "diff --stat branch1...branch2") {
+			t.Errorf("got %v, want %s", gitCommands, "diff --stat branch1...branch2")
+		}
+		if paged != " file.go | 2 +-" {
+			t.Errorf("got \"%s\", want \"%s\"", paged, " file.go | 2 +-")
+		}
+	})
+
+	t.Run("without the pager", func(t *testing.T) {
+		var gitCommands []string
+		stacksManager := diffStacksManager(&gitCommands)
+		var paged string
+		stacksManager.pager = pagerStub{content: &paged}
+
+		err := stacksManager.Diff(DiffOptions{Branch: "branch2", NameOnly: true, NoPager: true})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if !slices.Contains(gitCommands, "diff --name-only branch1...branch2") {
+			t.Errorf("got %v, want %s", gitCommands, "diff --name-only branch1...branch2")
+		}
+		if paged != "" || stacksManager.printerMessage() != " file.go | 2 +-\n" {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), " file.go | 2 +-\n")
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		var gitCommands []string
+		stacksManager := diffStacksManager(&gitCommands)
+		stacksManager.stacks.CurrentStack = "stack2"
+
+		err := stacksManager.Diff(DiffOptions{Branch: "branch4"})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		want := "No changes in " + color.Yellow("branch4") + " since " + color.Yellow("branch3") + "\n"
+		if stacksManager.printerMessage() != want {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("branch not in the stack", func(t *testing.T) {
+		var gitCommands []string
+		stacksManager := diffStacksManager(&gitCommands)
+
+		err := stacksManager.Diff(DiffOptions{Branch: "branch3"})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("invalid branch number", func(t *testing.T) {
+		var gitCommands []string
+		stacksManager := diffStacksManager(&gitCommands)
+
+		err := stacksManager.Diff(DiffOptions{Branch: "3"})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package stack
+
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"slices"
+)
+
+type doctorProblem struct {
+	description string
+	fixQuestion string
+	fix         func() error
+}
+
+// Doctor checks the stacks for problems and offers a fix for each of them:
+// 1. Current stack that does not exist
+// 2. Branches that do not exist locally
+// 3. Branches without upstream
+// 4. Branches in more than one stack
+// 5. Missing origin/HEAD
+func (sm StacksManager) Doctor() error {
+	sm.stacks.LoadStacks()
+
+	checks := []func() []doctorProblem{
+		sm.checkCurrentStack,
+		sm.checkMissingBranches,
+		sm.checkMissingUpstreams,
+		sm.checkDuplicatedBranches,
+		sm.checkRemoteHead,
+	}
+
+	var found, fixed int
+	// Each check runs after the fixes of the previous ones,
+	// e.g. a removed branch is not reported again as duplicated.
+	for _, check := range checks {
+		for _, problem := range check() {
+			found++
+			sm.printer.Println(color.Red("✗"), problem.description)
+			if !sm.prompter.Confirm(problem.fixQuestion) {
+				continue
+			}
+			err := problem.fix()
+			if err != nil {
+				// Keep the fixes already confirmed
+				if fixed > 0 {
+					sm.stacks.SaveStacks()
+				}
+				return err
+			}
+			fixed++
+		}
+	}
+
+	if found == 0 {
+		sm.printer.Println(color.Green("✓"), "No problem found")
+		return nil
+	}
+	if fixed > 0 {
+		sm.stacks.SaveStacks()
+	}
+	sm.printer.Println(fmt.Sprintf("%d problem(s) found, %d fixed", found, fixed))
+	return nil
+}
+
+func (sm StacksManager) checkCurrentStack() []doctorProblem {
+	data := sm.stacks
+	if _, err := data.GetStackByName(data.CurrentStack); err == nil {
+		return nil
+	}
+	if data.CurrentStack == "" && len(data.Stacks) == 0 {
+		return nil
+	}
+
+	newCurrentStack := ""
+	if len(data.Stacks) > 0 {
+		newCurrentStack = data.Stacks[0].Name
+	}
+
+	description := "Current stack " + color.Green(data.CurrentStack) + " does not exist"
+	if data.CurrentStack == "" {
+		description = "No current stack"
+	}
+	question := "Switch to stack " + newCurrentStack + "?"
+	if newCurrentStack == "" {
+		question = "Unset the current stack?"
+	}
+
+	return []doctorProblem{{
+		description: description,
+		fixQuestion: question,
+		fix: func() error {
+			data.CurrentStack = newCurrentStack
+			return nil
+		},
+	}}
+}
+
+func (sm StacksManager) checkMissingBranches() []doctorProblem {
+	var problems []doctorProblem
+	for i := range sm.stacks.Stacks {
+		stack := &sm.stacks.Stacks[i]
+		for _, branch := range stack.Branches {
+			branch := branch
+			if sm.localBranchExists(branch) {
+				continue
+			}
+			problems = append(problems, doctorProblem{
+				description: "Branch " + color.Yellow(branch) + " of stack " + color.Green(stack.Name) + " does not exist",
+				fixQuestion: "Remove " + branch + " from " + stack.Name + "?",
+				fix: func() error {
+					stack.Branches = slices.DeleteFunc(stack.Branches, func(b string) bool { return b == branch })
+					return nil
+				},
+			})
+		}
+	}
+	return problems
+}
+
+func (sm StacksManager) checkMissingUpstreams() []doctorProblem {
+	var problems []doctorProblem
+	for _, stack := range sm.stacks.Stacks {
+		for _, branch := range stack.Branches {
+			branch := branch
+			if sm.hasUpstream(branch) {
+				continue
+			}
+			problems = append(problems, doctorProblem{
+				description: "Branch " + color.Yellow(branch) + " of stack " + color.Green(stack.Name) + " has no upstream",
+				fixQuestion: "Publish " + branch + " to " + sm.remote() + "?",
+				fix: func() error {
+					return sm.publishBranch(branch)
+				},
+			})
+		}
+	}
+	return problems
+}
+
+func (sm StacksManager) checkDuplicatedBranches() []doctorProblem {
+	var problems []doctorProblem
+	firstStack := map[string]string{}
+	for i := range sm.stacks.Stacks {
+		stack := &sm.stacks.Stacks[i]
+		var seen []string
+		for _, branch := range stack.Branches {
+			branch := branch
+			if slices.Contains(seen, branch) {
+				problems = append(problems, doctorProblem{
+					description: "Branch " + color.Yellow(branch) + " is more than once in stack " + color.Green(stack.Name),
+					fixQuestion: "Keep only the first " + branch + " in " + stack.Name + "?",
+					fix: func() error {
+						index := slices.Index(stack.Branches, branch)
+						rest := slices.DeleteFunc(slices.Clone(stack.Branches[index+1:]), func(b string) bool { return b == branch })
+						stack.Branches = append(stack.Branches[:index+1], rest...)
+						return nil
+					},
+				})
+				continue
+			}
+			seen = append(seen, branch)
+
+			otherStack, ok := firstStack[branch]
+			if !ok {
+				firstStack[branch] = stack.Name
+				continue
+			}
+			problems = append(problems, doctorProblem{
+				description: "Branch " + color.Yellow(branch) + " is in stacks " + color.Green(otherStack) + " and " + color.Green(stack.Name),
+				fixQuestion: "Remove " + branch + " from " + stack.Name + "?",
+				fix: func() error {
+					stack.Branches = slices.DeleteFunc(stack.Branches, func(b string) bool { return b == branch })
+					return nil
+				},
+			})
+		}
+	}
+	return problems
+}
+
+func (sm StacksManager) checkRemoteHead() []doctorProblem {
+	if _, err := sm.defaultBranchWithRemote(); err == nil {
+		return nil
+	}
+	return []doctorProblem{{
+		description: "Default branch " + sm.remote() + "/HEAD is not set",
+		fixQuestion: "Set it from the remote with `git remote set-head " + sm.remote() + " --auto`?",
+		fix:         sm.setRemoteHead,
+	}}
+}
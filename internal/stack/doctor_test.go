@@ -0,0 +1,162 @@
+package stack
+
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStacksManager_Doctor(t *testing.T) {
+	t.Run("when no problem", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Doctor()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := color.Green("✓") + " No problem found"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("when fixes are refused", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				switch strings.Join(command, " ") {
+				case "rev-parse --verify refs/heads/branch2",
+					"rev-parse --abbrev-ref branch3@{upstream}",
+					"symbolic-ref refs/remotes/origin/HEAD --short":
+					return "", fmt.Errorf("git command error")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		var questionsReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.prompter = PrompterStub{QuestionsReceived: &questionsReceived}
+		stacksManager.stacks.CurrentStack = "deleted_stack"
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch3", "branch1"}
+
+		err := stacksManager.Doctor()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := []string{
+			"Switch to stack stack1?",
+			"Remove branch2 from stack1?",
+			"Publish branch3 to origin?",
+			"Remove branch1 from stack2?",
+			"Set it from the remote with `git remote set-head origin --auto`?",
+		}
+		if !reflect.DeepEqual(questionsReceived, want) {
+			t.Errorf("got %s, want %s", questionsReceived, want)
+		}
+
+		wantMessage := "5 problem(s) found, 0 fixed"
+		if !strings.Contains(stacksManager.printerMessage(), wantMessage) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), wantMessage)
+		}
+
+		if stacksManager.stacks.CurrentStack != "deleted_stack" {
+			t.Errorf("got %s, want %s", stacksManager.stacks.CurrentStack, "deleted_stack")
+		}
+	})
+
+	t.Run("when fixes are accepted", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				gitCommandsReceived = append(gitCommandsReceived, joinedCommand)
+				switch joinedCommand {
+				case "rev-parse --verify refs/heads/branch2",
+					"rev-parse --abbrev-ref branch3@{upstream}",
+					"symbolic-ref refs/remotes/origin/HEAD --short":
+					return "", fmt.Errorf("git command error")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.prompter = PrompterStub{Answer: true}
+		stacksManager.stacks.CurrentStack = "deleted_stack"
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch3", "branch1"}
+
+		err := stacksManager.Doctor()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		if stacksManager.stacks.CurrentStack != "stack1" {
+			t.Errorf("got %s, want %s", stacksManager.stacks.CurrentStack, "stack1")
+		}
+		if !reflect.DeepEqual(stacksManager.stacks.Stacks[0].Branches, []string{"branch1"}) {
+			t.Errorf("got %s, want %s", stacksManager.stacks.Stacks[0].Branches, []string{"branch1"})
+		}
+		if !reflect.DeepEqual(stacksManager.stacks.Stacks[1].Branches, []string{"branch3"}) {
+			t.Errorf("got %s, want %s", stacksManager.stacks.Stacks[1].Branches, []string{"branch3"})
+		}
+
+		for _, want := range []string{"push -u origin branch3", "remote set-head origin --auto"} {
+			if !strings.Contains(strings.Join(gitCommandsReceived, "\n"), want) {
+				t.Errorf("got %s, want %s", gitCommandsReceived, want)
+			}
+		}
+
+		wantMessage := "5 problem(s) found, 5 fixed"
+		if !strings.Contains(stacksManager.printerMessage(), wantMessage) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), wantMessage)
+		}
+	})
+
+	t.Run("when a fix fails, save the previous fixes", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				switch strings.Join(command, " ") {
+				case "rev-parse --verify refs/heads/branch2",
+					"rev-parse --abbrev-ref branch3@{upstream}",
+					"push -u origin branch3":
+					return "", fmt.Errorf("git command error")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.prompter = PrompterStub{Answer: true}
+		dir := t.TempDir()
+		_ = os.Mkdir(filepath.Join(dir, ".git"), 0755)
+		persister := StacksPersistingFile{Dir: dir}
+		stacksManager.stacks.StacksPersister = persister
+		stacksManager.stacks.CurrentStack = "deleted_stack"
+
+		err := stacksManager.Doctor()
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+
+		var saved StacksData
+		persister.LoadStacks(&saved)
+		if saved.CurrentStack != "stack1" {
+			t.Errorf("got %s, want %s", saved.CurrentStack, "stack1")
+		}
+		if len(saved.Stacks) == 0 || !reflect.DeepEqual(saved.Stacks[0].Branches, []string{"branch1"}) {
+			t.Errorf("got %v, want %s", saved.Stacks, []string{"branch1"})
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"slices"
+	"strings"
+)
+
+const editTodoHelp = `
+# Edit stack %s
+#
+# Commands:
+# k, keep <branch> = keep the branch in the stack
+# d, drop <branch> = remove the branch from the stack
+# r, rename <branch> <new-name> = rename the branch and keep it in the stack
+#
+# The lines can be reordered, the first line is the bottom of the stack.
+# If you remove a line, the branch is dropped from the stack.
+# However, if no branch is left, the edit will be aborted.
+`
+
+type editAction struct {
+	command string
+	branch  string
+	newName string
+}
+
+type editResult struct {
+	branches []string
+	renames  map[string]string
+	dropped  []string
+}
+
+// Edit opens the branches of the current stack in the editor as a todo file
+// and applies the result to the stack once the editor is closed.
+func (sm StacksManager) Edit() error {
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	stack, err := data.GetStackByName(data.CurrentStack)
+	if err != nil {
+		return err
+	}
+
+	content, err := sm.editTodo(stack)
+	if err != nil {
+		return err
+	}
+
+	actions, err := parseEditTodo(content)
+	if err != nil {
+		return err
+	}
+	result, err := applyEditActions(stack.Branches, actions)
+	if err != nil {
+		return err
+	}
+	// Removing or dropping every branch aborts the edit, like an empty rebase todo
+	if len(result.branches) == 0 {
+		sm.printer.Println("Nothing to do")
+		return nil
+	}
+
+	for _, oldName := range renamedInOrder(stack.Branches, result.renames) {
+		if sm.branchExists(result.renames[oldName]) {
+			return errors.New("branch " + color.Yellow(result.renames[oldName]) + " already exists")
+		}
+	}
+
+	// Rename the git branches first, the stacks are only saved when every rename succeed.
+	var renamed []string
+	for _, oldName := range renamedInOrder(stack.Branches, result.renames) {
+		err = sm.renameBranch(oldName, result.renames[oldName])
+		if err != nil {
+			for _, done := range renamed {
+				_ = sm.renameBranch(result.renames[done], done)
+			}
+			return err
+		}
+		renamed = append(renamed, oldName)
+	}
+
+	for oldName, newName := range result.renames {
+		data.RenameBranch(oldName, newName)
+	}
+	stack.Branches = result.branches
+	data.SaveStacks()
+
+	for _, oldName := range renamed {
+		sm.printer.Println("Branch", color.Yellow(oldName), "renamed to", color.Yellow(result.renames[oldName]))
+	}
+	for _, branch := range result.dropped {
+		sm.printer.Println("Branch", color.Yellow(branch), "removed from", color.Green(stack.Name))
+	}
+	sm.printer.Println("Stack", color.Green(stack.Name), "updated")
+	return nil
+}
+
+func (sm StacksManager) editTodo(stack *Stack) (string, error) {
+	file, err := os.CreateTemp("", "gostacking-edit-*.txt")
+	if err != nil {
+		return "", errors.New("failed to create the edit file\n" + err.Error())
+	}
+	defer os.Remove(file.Name())
+
+	var todo string
+	for _, branch := range stack.Branches {
+		todo += "keep " + branch + "\n"
+	}
+	todo += fmt.Sprintf(editTodoHelp, stack.Name)
+
+	_, err = file.WriteString(todo)
+	file.Close()
+	if err != nil {
+		return "", errors.New("failed to write the edit file\n" + err.Error())
+	}
+
+	err = sm.editor.Edit(file.Name())
+	if err != nil {
+		return "", errors.New("editor failed\n" + err.Error())
+	}
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", errors.New("failed to read the edit file\n" + err.Error())
+	}
+	return string(content), nil
+}
+
+func parseEditTodo(content string) ([]editAction, error) {
+	var actions []editAction
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		action := editAction{command: fields[0]}
+		switch action.command {
+		case "k", "keep":
+			action.command = "keep"
+		case "d", "drop":
+			action.command = "drop"
+		case "r", "rename":
+			action.command = "rename"
+		default:
+			return nil, fmt.Errorf("line %d: unknown command %s", i+1, fields[0])
+		}
+
+		if action.command == "rename" {
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: rename expects a branch and a new name", i+1)
+			}
+			action.newName = fields[2]
+		} else if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: %s expects a branch", i+1, action.command)
+		}
+		action.branch = fields[1]
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// applyEditActions computes the new branches of the stack.
+// Branches missing from the actions are dropped.
+func applyEditActions(branches []string, actions []editAction) (editResult, error) {
+	result := editResult{renames: map[string]string{}}
+	var seen []string
+	for _, action := range actions {
+		if !slices.Contains(branches, action.branch) {
+			return editResult{}, errors.New("branch " + color.Yellow(action.branch) + " is not part of the stack")
+		}
+		if slices.Contains(seen, action.branch) {
+			return editResult{}, errors.New("branch " + color.Yellow(action.branch) + " is listed more than once")
+		}
+		seen = append(seen, action.branch)
+
+		switch action.command {
+		case "keep":
+			result.branches = append(result.branches, action.branch)
+		case "rename":
+			if action.newName == action.branch {
+				result.branches = append(result.branches, action.branch)
+				continue
+			}
+			if slices.Contains(branches, action.newName) || slices.Contains(result.branches, action.newName) {
+				return editResult{}, errors.New("branch " + color.Yellow(action.newName) + " is already part of the stack")
+			}
+			result.renames[action.branch] = action.newName
+			result.branches = append(result.branches, action.newName)
+		case "drop":
+			result.dropped = append(result.dropped, action.branch)
+		}
+	}
+
+	for _, branch := range branches {
+		if !slices.Contains(seen, branch) {
+			result.dropped = append(result.dropped, branch)
+		}
+	}
+	return result, nil
+}
+
+// renamedInOrder returns the renamed branches in the stack order to keep the output deterministic.
+func renamedInOrder(branches []string, renames map[string]string) []string {
+	var keys []string
+	for _, branch := range branches {
+		if _, ok := renames[branch]; ok {
+			keys = append(keys, branch)
+		}
+	}
+	return keys
+}
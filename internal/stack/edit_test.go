@@ -0,0 +1,224 @@
+package stack
+
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type EditorStub struct {
+	stubEdit func(content string) string
+}
+
+func (e EditorStub) Edit(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(e.stubEdit(string(content))), 0644)
+}
+
+func TestStacksManager_Edit(t *testing.T) {
+	t.Run("todo file lists the branches of the current stack", func(t *testing.T) {
+		var todoReceived string
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+		stacksManager.editor = EditorStub{
+			stubEdit: func(content string) string {
+				todoReceived = content
+				return content
+			},
+		}
+
+		err := stacksManager.Edit()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := "keep branch1\nkeep branch2\n\n# Edit stack stack1\n"
+		if !strings.HasPrefix(todoReceived, want) {
+			t.Errorf("got \"%s\", want \"%s\"", todoReceived, want)
+		}
+	})
+
+	t.Run("reorder, rename and drop branches", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommandsReceived = append(gitCommandsReceived, strings.Join(command, " "))
+				if command[0] == "rev-parse" {
+					return "", fmt.Errorf("branch does not exist")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.Stacks[0].Branches = []string{"branch1", "branch2", "branch5"}
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch2", "branch4"}
+		stacksManager.editor = EditorStub{
+			stubEdit: func(content string) string {
+				return "rename branch2 feature\nk branch1\nd branch5\n"
+			},
+		}
+
+		err := stacksManager.Edit()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := []string{"rev-parse --verify feature", "branch -m branch2 feature"}
+		if !reflect.DeepEqual(gitCommandsReceived, want) {
+			t.Errorf("got %s, want %s", gitCommandsReceived, want)
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"feature", "branch1"}) {
+			t.Errorf("got %s, want %s", got, []string{"feature", "branch1"})
+		}
+
+		got = stacksManager.stacks.Stacks[1].Branches
+		if !reflect.DeepEqual(got, []string{"feature", "branch4"}) {
+			t.Errorf("got %s, want %s", got, []string{"feature", "branch4"})
+		}
+
+		wantMessage := "Branch " + color.Yellow("branch5") + " removed from " + color.Green("stack1")
+		if !strings.Contains(stacksManager.printerMessage(), wantMessage) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), wantMessage)
+		}
+	})
+
+	t.Run("when the todo file is empty", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+		stacksManager.editor = EditorStub{
+			stubEdit: func(content string) string {
+				return "# nothing\n"
+			},
+		}
+
+		err := stacksManager.Edit()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		if !strings.Contains(stacksManager.printerMessage(), "Nothing to do") {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), "Nothing to do")
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"branch1", "branch2"}) {
+			t.Errorf("got %s, want %s", got, []string{"branch1", "branch2"})
+		}
+	})
+
+	t.Run("when every branch is dropped", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+		stacksManager.editor = EditorStub{
+			stubEdit: func(content string) string {
+				return "drop branch1\nd branch2\n"
+			},
+		}
+
+		err := stacksManager.Edit()
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		if !strings.Contains(stacksManager.printerMessage(), "Nothing to do") {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), "Nothing to do")
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"branch1", "branch2"}) {
+			t.Errorf("got %s, want %s", got, []string{"branch1", "branch2"})
+		}
+	})
+
+	t.Run("when a rename fails, previous renames are reverted", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				gitCommandsReceived = append(gitCommandsReceived, joinedCommand)
+				if command[0] == "rev-parse" || joinedCommand == "branch -m branch2 new2" {
+					return "", fmt.Errorf("git command error")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.editor = EditorStub{
+			stubEdit: func(content string) string {
+				return "rename branch1 new1\nrename branch2 new2\n"
+			},
+		}
+
+		err := stacksManager.Edit()
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+
+		if gitCommandsReceived[len(gitCommandsReceived)-1] != "branch -m new1 branch1" {
+			t.Errorf("rename of branch1 should have been reverted, got %s", gitCommandsReceived)
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"branch1", "branch2"}) {
+			t.Errorf("got %s, want %s", got, []string{"branch1", "branch2"})
+		}
+	})
+}
+
+func TestParseEditTodo(t *testing.T) {
+	t.Run("parse commands", func(t *testing.T) {
+		actions, err := parseEditTodo("keep a\n  # comment\n\nd b\nr c d\n")
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := []editAction{
+			{command: "keep", branch: "a"},
+			{command: "drop", branch: "b"},
+			{command: "rename", branch: "c", newName: "d"},
+		}
+		if !reflect.DeepEqual(actions, want) {
+			t.Errorf("got %v, want %v", actions, want)
+		}
+	})
+
+	t.Run("when the command is unknown", func(t *testing.T) {
+		_, err := parseEditTodo("keep a\npick b\n")
+		if err == nil || err.Error() != "line 2: unknown command pick" {
+			t.Errorf("got %v, want %s", err, "line 2: unknown command pick")
+		}
+	})
+
+	t.Run("when rename has no new name", func(t *testing.T) {
+		_, err := parseEditTodo("rename a\n")
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
+
+func TestApplyEditActions(t *testing.T) {
+	t.Run("when a branch is not part of the stack", func(t *testing.T) {
+		_, err := applyEditActions([]string{"a"}, []editAction{{command: "keep", branch: "b"}})
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("when a branch is listed twice", func(t *testing.T) {
+		_, err := applyEditActions([]string{"a"}, []editAction{{command: "keep", branch: "a"}, {command: "drop", branch: "a"}})
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
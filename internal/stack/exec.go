@@ -0,0 +1,201 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ExecOptions struct {
+	// FailFast skips the remaining branches after the first failure
+	FailFast bool
+	// Parallel is the number of branches running the command at the same time
+	Parallel int
+}
+
+const (
+	execPassed  = "passed"
+	execFailed  = "failed"
+	execSkipped = "skipped"
+)
+
+type execResult struct {
+	branch   string
+	status   string
+	duration time.Duration
+	output   string
+}
+
+// ExecEach runs command on each branch of the current stack, in order, and prints
+// a pass/fail result for each branch. Each branch is checked out in its own temporary
+// worktree so the working directory is left untouched and branches can run in parallel.
+// A single argument is run with sh so it can be a script like "make build && make test".
+func (sm StacksManager) ExecEach(command []string, options ExecOptions) error {
+	if len(command) == 1 {
+		command = shellCommand(command[0])
+	}
+
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	branches, err := data.GetBranchesByName(data.CurrentStack)
+	if err != nil {
+		return err
+	}
+
+	worktreesDir, err := os.MkdirTemp("", "gostacking-exec-")
+	if err != nil {
+		return errors.New("failed to create the worktrees directory\n" + err.Error())
+	}
+	worktrees := &execWorktrees{dir: worktreesDir}
+	defer sm.removeWorktrees(worktrees)
+
+	results := make([]execResult, len(branches))
+	for i, branch := range branches {
+		results[i] = execResult{branch: branch, status: execSkipped}
+	}
+
+	// mutex guards failed and the printer
+	var mutex sync.Mutex
+	failed := false
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(options.Parallel, 1), len(branches)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				mutex.Lock()
+				skip := sm.interrupted() || (options.FailFast && failed)
+				mutex.Unlock()
+				if skip {
+					continue
+				}
+
+				result := sm.execBranch(command, data.CurrentStack, branches, i, worktrees)
+
+				mutex.Lock()
+				results[i] = result
+				failed = failed || result.status == execFailed
+				sm.printer.Println(execSymbol(result.status), color.Yellow(result.branch), result.status, "in", result.duration.Round(time.Millisecond))
+				mutex.Unlock()
+			}
+		}()
+	}
+	for i := range branches {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return sm.printExecResults(data.CurrentStack, results)
+}
+
+// execWorktrees are the temporary worktrees of ExecEach, they are added one at a time
+// since git worktree add locks the repository.
+type execWorktrees struct {
+	dir   string
+	mutex sync.Mutex
+	paths []string
+}
+
+func (sm StacksManager) execBranch(command []string, stackName string, branches []string, i int, worktrees *execWorktrees) execResult {
+	start := time.Now()
+	result := execResult{branch: branches[i], status: execFailed}
+	worktree := filepath.Join(worktrees.dir, strconv.Itoa(i+1))
+
+	worktrees.mutex.Lock()
+	err := sm.addWorktree(worktree, branches[i])
+	if err == nil {
+		worktrees.paths = append(worktrees.paths, worktree)
+	}
+	worktrees.mutex.Unlock()
+	if err != nil {
+		result.output = err.Error()
+		result.duration = time.Since(start)
+		return result
+	}
+
+	result.output, err = sm.runner.Run(worktree, branchEnv(stackName, branches, i), command...)
+	result.duration = time.Since(start)
+	if err == nil {
+		result.status = execPassed
+	}
+	return result
+}
+
+func shellCommand(script string) []string {
+	return []string{"sh", "-c", script}
+}
+
+// branchEnv are the environment variables given to the commands run for a branch of a stack.
+func branchEnv(stackName string, branches []string, i int) []string {
+	parent := ""
+	if i > 0 {
+		parent = branches[i-1]
+	}
+	return branchEnvWithParent(stackName, branches, i, parent)
+}
+
+// branchEnvWithParent is branchEnv with another parent than the previous branch,
+// like the default branch merged in the first one.
+func branchEnvWithParent(stackName string, branches []string, i int, parent string) []string {
+	return []string{
+		"GOSTACKING_STACK=" + stackName,
+		"GOSTACKING_BRANCH=" + branches[i],
+		"GOSTACKING_PARENT=" + parent,
+		"GOSTACKING_POSITION=" + strconv.Itoa(i+1),
+	}
+}
+
+func (sm StacksManager) removeWorktrees(worktrees *execWorktrees) {
+	for _, path := range worktrees.paths {
+		_ = sm.removeWorktree(path)
+	}
+	_ = os.RemoveAll(worktrees.dir)
+	_ = sm.pruneWorktrees()
+}
+
+func (sm StacksManager) printExecResults(stackName string, results []execResult) error {
+	var failed []execResult
+	var skipped int
+	output := "\nResults for " + color.Green(stackName) + ":\n"
+	for i, result := range results {
+		output += fmt.Sprintf("%d. %s %s\n", i+1, execSymbol(result.status), color.Yellow(result.branch))
+		switch result.status {
+		case execFailed:
+			failed = append(failed, result)
+		case execSkipped:
+			skipped++
+		}
+	}
+	sm.printer.Println(strings.TrimSuffix(output, "\n"))
+
+	for _, result := range failed {
+		sm.printer.Println("\nOutput of", color.Yellow(result.branch)+":\n"+result.output)
+	}
+
+	if sm.interrupted() {
+		return errors.New("interrupted")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("command failed on %d branch(es), %d skipped", len(failed), skipped)
+	}
+	return nil
+}
+
+func execSymbol(status string) string {
+	switch status {
+	case execPassed:
+		return color.Green("✓")
+	case execFailed:
+		return color.Red("✗")
+	default:
+		return "-"
+	}
+}
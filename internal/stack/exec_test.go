@@ -0,0 +1,179 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type commandRunnerStub struct {
+	stubRun func(dir string, env []string, command ...string) (string, error)
+}
+
+func (r commandRunnerStub) Run(dir string, env []string, command ...string) (string, error) {
+	return r.stubRun(dir, env, command...)
+}
+
+func execStacksManager(runner commandRunnerStub, messageReceived *[]string) (StacksManager, *[]string) {
+	var gitCommands []string
+	var mutex sync.Mutex
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			gitCommands = append(gitCommands, strings.Join(command[:2], " "))
+			return "", nil
+		},
+	}
+	stacksManager := StacksManagerForTest(gitExecutor, messageReceived)
+	stacksManager.runner = runner
+	return stacksManager, &gitCommands
+}
+
+func TestStacksManager_ExecEach(t *testing.T) {
+	t.Run("when all branches pass", func(t *testing.T) {
+		var envs [][]string
+		runner := commandRunnerStub{
+			stubRun: func(dir string, env []string, command ...string) (string, error) {
+				envs = append(envs, env)
+				return "ok", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, gitCommands := execStacksManager(runner, &messageReceived)
+
+		err := stacksManager.ExecEach([]string{"make", "test"}, ExecOptions{})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		want := fmt.Sprintf("Results for %s:\n1. %s %s\n2. %s %s",
+			color.Green("stack1"),
+			color.Green("✓"), color.Yellow("branch1"),
+			color.Green("✓"), color.Yellow("branch2"),
+		)
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+
+		wantEnv := []string{"GOSTACKING_STACK=stack1", "GOSTACKING_BRANCH=branch2", "GOSTACKING_PARENT=branch1", "GOSTACKING_POSITION=2"}
+		if len(envs) != 2 || !reflect.DeepEqual(envs[1], wantEnv) {
+			t.Errorf("got %v, want %v", envs, wantEnv)
+		}
+
+		wantGit := []string{"worktree add", "worktree add", "worktree remove", "worktree remove", "worktree prune"}
+		if !reflect.DeepEqual(*gitCommands, wantGit) {
+			t.Errorf("got %v, want %v", *gitCommands, wantGit)
+		}
+	})
+
+	t.Run("when a branch fails", func(t *testing.T) {
+		runner := commandRunnerStub{
+			stubRun: func(dir string, env []string, command ...string) (string, error) {
+				if slices.Contains(env, "GOSTACKING_BRANCH=branch1") {
+					return "FAIL: TestSomething", errors.New("exit status 1")
+				}
+				return "ok", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, _ := execStacksManager(runner, &messageReceived)
+
+		err := stacksManager.ExecEach([]string{"make test"}, ExecOptions{})
+
+		if err == nil || err.Error() != "command failed on 1 branch(es), 0 skipped" {
+			t.Errorf("got %v, want %s", err, "command failed on 1 branch(es), 0 skipped")
+		}
+		want := fmt.Sprintf("1. %s %s\n2. %s %s",
+			color.Red("✗"), color.Yellow("branch1"),
+			color.Green("✓"), color.Yellow("branch2"),
+		)
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+		want = "Output of " + color.Yellow("branch1") + ":\nFAIL: TestSomething"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("with fail fast", func(t *testing.T) {
+		runs := 0
+		runner := commandRunnerStub{
+			stubRun: func(dir string, env []string, command ...string) (string, error) {
+				runs++
+				return "", errors.New("exit status 1")
+			},
+		}
+		var messageReceived []string
+		stacksManager, _ := execStacksManager(runner, &messageReceived)
+
+		err := stacksManager.ExecEach([]string{"make test"}, ExecOptions{FailFast: true})
+
+		if err == nil || err.Error() != "command failed on 1 branch(es), 1 skipped" {
+			t.Errorf("got %v, want %s", err, "command failed on 1 branch(es), 1 skipped")
+		}
+		if runs != 1 {
+			t.Errorf("got %d runs, want 1", runs)
+		}
+		want := "2. - " + color.Yellow("branch2")
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("in parallel", func(t *testing.T) {
+		// Both branches must run at the same time to pass the barrier
+		var barrier sync.WaitGroup
+		barrier.Add(2)
+		runner := commandRunnerStub{
+			stubRun: func(dir string, env []string, command ...string) (string, error) {
+				barrier.Done()
+				barrier.Wait()
+				return "ok", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, _ := execStacksManager(runner, &messageReceived)
+
+		err := stacksManager.ExecEach([]string{"make test"}, ExecOptions{Parallel: 2})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+	})
+
+	t.Run("when the worktree can't be created", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[1] == "add" {
+					return "fatal: invalid reference: branch1", errors.New("exit status 128")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.runner = commandRunnerStub{
+			stubRun: func(dir string, env []string, command ...string) (string, error) {
+				t.Errorf("command should not run without worktree")
+				return "", nil
+			},
+		}
+
+		err := stacksManager.ExecEach([]string{"make test"}, ExecOptions{})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+		want := "failed to create a worktree for " + color.Yellow("branch1") + "\nfatal: invalid reference: branch1"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+}
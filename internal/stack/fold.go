@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"slices"
+)
+
+type FoldOptions struct {
+	// Branch is the name or the number of a branch of the current stack, the current branch when empty
+	Branch string
+	// Push pushes the parent after the merge
+	Push bool
+	// ClosePr closes the pull request of the branch with GH-CLI
+	ClosePr bool
+	// Retarget changes the base of the pull request of the next branch to the parent with GH-CLI, it needs Push
+	Retarget bool
+}
+
+// Fold merges a branch of the current stack into its parent and removes it from the stack,
+// the next branch then follows the parent. The git branch is kept.
+func (sm StacksManager) Fold(options FoldOptions) error {
+	// Without the merge on the remote, the pull request of the next branch would show the commits of the folded branch again
+	if options.Retarget && !options.Push {
+		return errors.New("--retarget needs --push, the parent must have the changes of the branch on the remote")
+	}
+
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	stack, err := data.GetStackByName(data.CurrentStack)
+	if err != nil {
+		return err
+	}
+	i, err := sm.stackBranchIndex(stack.Branches, options.Branch)
+	if err != nil {
+		return err
+	}
+	branch := stack.Branches[i]
+	if i == 0 {
+		return errors.New("branch " + color.Yellow(branch) + " is the first of the stack, it can't be folded into the default branch")
+	}
+	parent := stack.Branches[i-1]
+	var child string
+	if i < len(stack.Branches)-1 {
+		child = stack.Branches[i+1]
+	}
+
+	if sm.unstagedChanges() {
+		return errors.New("unstaged changes, please commit or stash them")
+	}
+	currentBranch, err := sm.currentBranchName()
+	if err != nil {
+		return err
+	}
+
+	sm.printer.Println("Folding", color.Yellow(branch), "into", color.Yellow(parent)+"...")
+	err = sm.checkout(parent)
+	if err != nil {
+		return err
+	}
+	err = sm.merge(parent, branch)
+	if err != nil {
+		return err
+	}
+	sm.runPostMergeHook(data.CurrentStack, stack.Branches, i-1, branch)
+	if options.Push {
+		sm.printer.Println("\tPushing...")
+		err = sm.pushBranch()
+		if err != nil {
+			return err
+		}
+	}
+
+	stack.Branches = slices.Delete(stack.Branches, i, i+1)
+	data.SaveStacks()
+	sm.printer.Println("Branch", color.Yellow(branch), "folded into", color.Yellow(parent), "and removed from", color.Green(data.CurrentStack))
+
+	// The folded branch is not in the stack anymore, stay on its parent
+	if currentBranch != branch && currentBranch != parent {
+		err = sm.checkout(currentBranch)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !options.ClosePr && !options.Retarget {
+		return nil
+	}
+	err = sm.ghCliConfigure()
+	if err != nil {
+		return err
+	}
+	if options.Retarget && child != "" {
+		if prNumber, err := sm.ghPrNumber(child); err != nil || prNumber == "" {
+			sm.printer.Println("No pull request for", color.Yellow(child))
+		} else {
+			err = sm.ghRetargetPr(child, parent)
+			if err != nil {
+				return err
+			}
+			sm.printer.Println("Pull request", "#"+prNumber, "of", color.Yellow(child), "retargeted to", color.Yellow(parent))
+		}
+	}
+	if options.ClosePr {
+		if prNumber, err := sm.ghPrNumber(branch); err != nil || prNumber == "" {
+			sm.printer.Println("No pull request for", color.Yellow(branch))
+		} else {
+			err = sm.ghClosePr(branch, "Folded into "+parent+" (gostacking)")
+			if err != nil {
+				return err
+			}
+			sm.printer.Println("Pull request", "#"+prNumber, "of", color.Yellow(branch), "closed")
+		}
+	}
+	return nil
+}
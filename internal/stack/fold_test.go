@@ -0,0 +1,168 @@
+package stack
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStacksManager_Fold(t *testing.T) {
+	t.Run("merge the current branch into its parent", func(t *testing.T) {
+		var gitCommands, ghCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				if strings.Join(command, " ") == "rev-parse --abbrev-ref HEAD" {
+					return "branch4", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, runs := hooksStacksManager(gitExecutor, map[string][]string{HookPostMerge: {"npm", "install"}}, &messageReceived)
+		stacksManager.stacks.CurrentStack = "stack2"
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch3", "branch4", "branch5"}
+		stacksManager.ghExecutor = cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				ghCommands = append(ghCommands, strings.Join(command, " "))
+				return "", nil
+			},
+		}
+
+		err := stacksManager.Fold(FoldOptions{Push: true})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		want := []string{
+			"rev-parse --abbrev-ref HEAD",
+			"status --porcelain",
+			"rev-parse --abbrev-ref HEAD",
+			"checkout branch3",
+			"merge branch4 -m Merge branch branch4 into branch3 (gostacking)",
+			"rev-parse branch3",
+			"rev-parse branch4",
+			"push",
+		}
+		if !reflect.DeepEqual(gitCommands, want) {
+			t.Errorf("got %v, want %v", gitCommands, want)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack2")
+		if !reflect.DeepEqual(branches, []string{"branch3", "branch5"}) {
+			t.Errorf("got %v, want %v", branches, []string{"branch3", "branch5"})
+		}
+		if len(ghCommands) != 0 {
+			t.Errorf("got %v, want no GH-CLI command", ghCommands)
+		}
+		if len(*runs) != 1 || !slices.Contains((*runs)[0].env, "GOSTACKING_BRANCH=branch3") || !slices.Contains((*runs)[0].env, "GOSTACKING_PARENT=branch4") {
+			t.Errorf("got %v, want post-merge of branch3 with branch4", *runs)
+		}
+	})
+
+	t.Run("close its pull request and retarget the next one", func(t *testing.T) {
+		var ghCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if strings.Join(command, " ") == "rev-parse --abbrev-ref HEAD" {
+					return "branch4", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.CurrentStack = "stack2"
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch3", "branch4", "branch5"}
+		stacksManager.ghExecutor = cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				ghCommands = append(ghCommands, strings.Join(command, " "))
+				if command[0] == "pr" && command[1] == "view" {
+					return map[string]string{"branch4": "12", "branch5": "13"}[command[2]], nil
+				}
+				return "", nil
+			},
+		}
+
+		err := stacksManager.Fold(FoldOptions{Branch: "2", Push: true, ClosePr: true, Retarget: true})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		for _, want := range []string{
+			"pr edit branch5 --base branch3",
+			"pr close branch4 --comment Folded into branch3 (gostacking)",
+		} {
+			if !slices.Contains(ghCommands, want) {
+				t.Errorf("got %v, want %s", ghCommands, want)
+			}
+		}
+		want := "Pull request #13 of branch5 retargeted to branch3"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("go back to the current branch", func(t *testing.T) {
+		var gitCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				if strings.Join(command, " ") == "rev-parse --abbrev-ref HEAD" {
+					return "branch5", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.CurrentStack = "stack2"
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch3", "branch4", "branch5"}
+
+		err := stacksManager.Fold(FoldOptions{Branch: "branch4"})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if gitCommands[len(gitCommands)-1] != "checkout branch5" {
+			t.Errorf("got %v, want checkout branch5 at the end", gitCommands)
+		}
+	})
+
+	t.Run("retarget without push", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				t.Errorf("got %s, want no git command", command)
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Fold(FoldOptions{Branch: "2", Retarget: true})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("the first branch", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "merge" {
+					t.Errorf("got %s, want no merge", command)
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.CurrentStack = "stack2"
+
+		err := stacksManager.Fold(FoldOptions{Branch: "1"})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
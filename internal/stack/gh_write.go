@@ -0,0 +1,38 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+)
+
+// ghRenameRemoteBranch renames the branch on GitHub.
+// GitHub updates the head and the base of the pull requests using this branch.
+func (sm StacksManager) ghRenameRemoteBranch(oldName string, newName string) error {
+	output, err := sm.ghExecutor.Exec(
+		"api",
+		"--method", "POST",
+		"repos/{owner}/{repo}/branches/"+oldName+"/rename",
+		"-f", "new_name="+newName,
+	)
+	if err != nil {
+		return errors.New("failed to rename " + color.Yellow(oldName) + " on GitHub\n" + output)
+	}
+	return nil
+}
+
+// ghRetargetPr changes the base branch of the pull request of branchName.
+func (sm StacksManager) ghRetargetPr(branchName string, base string) error {
+	output, err := sm.ghExecutor.Exec("pr", "edit", branchName, "--base", base)
+	if err != nil {
+		return errors.New("failed to retarget the pull request of " + color.Yellow(branchName) + "\n" + output)
+	}
+	return nil
+}
+
+func (sm StacksManager) ghClosePr(branchName string, comment string) error {
+	output, err := sm.ghExecutor.Exec("pr", "close", branchName, "--comment", comment)
+	if err != nil {
+		return errors.New("failed to close the pull request of " + color.Yellow(branchName) + "\n" + output)
+	}
+	return nil
+}
@@ -3,10 +3,16 @@ package stack
 import (
 	"errors"
 	"github.com/Bhacaz/gostacking/internal/color"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 func (sm StacksManager) currentBranchName() (string, error) {
+	if sm.nativeGit != nil {
+		return sm.nativeGit.currentBranchName()
+	}
 	currentBranch, err := sm.gitExecutor.Exec("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", errors.New("failed to get current branch")
@@ -15,16 +21,32 @@ func (sm StacksManager) currentBranchName() (string, error) {
 }
 
 func (sm StacksManager) branchExists(branchName string) bool {
+	if sm.nativeGit != nil {
+		return sm.nativeGit.branchExists(branchName)
+	}
 	_, err := sm.gitExecutor.Exec("rev-parse", "--verify", branchName)
 	return err == nil
 }
 
-func (sm StacksManager) branchHasDiff(parentBranch string, branch string) (bool, error) {
-	output, err := sm.gitExecutor.Exec("diff", "--name-only", branch+"..."+parentBranch)
-	if err != nil {
-		return false, errors.New("failed to get diff\n" + output)
-	}
-	return len(output) > 0, nil
+// isAncestor is true when commit is part of branch.
+func (sm StacksManager) isAncestor(commit string, branch string) bool {
+	_, err := sm.gitExecutor.Exec("merge-base", "--is-ancestor", commit, branch)
+	return err == nil
+}
+
+func (sm StacksManager) localBranchExists(branchName string) bool {
+	_, err := sm.gitExecutor.Exec("rev-parse", "--verify", "refs/heads/"+branchName)
+	return err == nil
+}
+
+func (sm StacksManager) hasUpstream(branchName string) bool {
+	_, err := sm.gitExecutor.Exec("rev-parse", "--abbrev-ref", branchName+"@{upstream}")
+	return err == nil
+}
+
+func (sm StacksManager) remoteBranchExists(branchName string) bool {
+	_, err := sm.gitExecutor.Exec("rev-parse", "--verify", sm.remote()+"/"+branchName)
+	return err == nil
 }
 
 func (sm StacksManager) lastLog(branch string) string {
@@ -35,37 +57,34 @@ func (sm StacksManager) lastLog(branch string) string {
 	return output
 }
 
-func (sm StacksManager) isBehindRemote(branch string) bool {
-	output, err := sm.gitExecutor.Exec("diff", "--name-only", branch+"...origin/"+branch)
-	if err != nil {
-		return false
+// commitsCount returns the number of non-merge commits reachable from branch
+// but not from any of the excluded branches.
+func (sm StacksManager) commitsCount(branch string, excluded ...string) (int, error) {
+	args := []string{"rev-list", "--count", "--no-merges", branch}
+	for _, excludedBranch := range excluded {
+		args = append(args, "^"+excludedBranch)
 	}
-	return len(output) > 0
-}
-
-func (sm StacksManager) aheadRemote(branch string) bool {
-	output, err := sm.gitExecutor.Exec("diff", "--name-only", "origin/"+branch+"..."+branch)
+	output, err := sm.gitExecutor.Exec(args...)
 	if err != nil {
-		return false
+		return 0, errors.New("failed to count commits\n" + output)
 	}
-	return len(output) > 0
-}
-
-func (sm StacksManager) behindDefaultBranch(branch string) bool {
-	defaultBranch, err := sm.defaultBranchWithRemote()
+	count, err := strconv.Atoi(strings.TrimSpace(output))
 	if err != nil {
-		return false
+		return 0, errors.New("failed to count commits\n" + output)
 	}
+	return count, nil
+}
 
-	output, err := sm.gitExecutor.Exec("diff", "--name-only", branch+"..."+defaultBranch)
+func (sm StacksManager) fetch() error {
+	_, err := sm.gitExecutor.Exec("fetch", sm.remote())
 	if err != nil {
-		return false
+		return errors.New("failed to fetch")
 	}
-	return len(output) > 0
+	return nil
 }
 
-func (sm StacksManager) fetch() error {
-	_, err := sm.gitExecutor.Exec("fetch")
+func (sm StacksManager) fetchPrune() error {
+	_, err := sm.gitExecutor.Exec("fetch", "--prune", sm.remote())
 	if err != nil {
 		return errors.New("failed to fetch")
 	}
@@ -81,12 +100,17 @@ func (sm StacksManager) unstagedChanges() bool {
 }
 
 func (sm StacksManager) defaultBranchWithRemote() (string, error) {
-	main, err := sm.gitExecutor.Exec("symbolic-ref", "refs/remotes/origin/HEAD", "--short")
+	return sm.cache.defaultBranchWithRemote(func() (string, error) {
+		if sm.nativeGit != nil {
+			return sm.nativeGit.defaultBranchWithRemote(sm.remote())
+		}
+		main, err := sm.gitExecutor.Exec("symbolic-ref", "refs/remotes/"+sm.remote()+"/HEAD", "--short")
 
-	if err != nil {
-		return "", errors.New("Error getting origin default main branch:\n To set it try: " + color.Teal("git remote set-head origin <<main branch>>"))
-	}
-	return main, nil
+		if err != nil {
+			return "", errors.New("Error getting " + sm.remote() + " default main branch:\n To set it try: " + color.Teal("git remote set-head "+sm.remote()+" <<main branch>>"))
+		}
+		return main, nil
+	})
 }
 
 func (sm StacksManager) defaultBranch() (string, error) {
@@ -95,24 +119,195 @@ func (sm StacksManager) defaultBranch() (string, error) {
 		return "", err
 	}
 
-	return strings.Replace(branch, "origin/", "", 1), nil
+	return strings.Replace(branch, sm.remote()+"/", "", 1), nil
+}
+
+// commitFormat separates the fields with the unit separator, it can't be part of a commit subject.
+const commitFormat = "--pretty=format:%h%x1f%s%x1f%an%x1f%cI%x1f%cr"
+
+// parseCommits parses the commits of commitFormat, with the stat following each commit with --shortstat.
+func parseCommits(output string) []CommitReport {
+	var commits []CommitReport
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			if strings.Contains(line, " changed") && len(commits) > 0 {
+				stat := parseShortStat(line)
+				commits[len(commits)-1].Stat = &stat
+			}
+			continue
+		}
+		commits = append(commits, CommitReport{
+			Hash:         fields[0],
+			Subject:      fields[1],
+			Author:       fields[2],
+			Date:         fields[3],
+			RelativeDate: fields[4],
+		})
+	}
+	return commits
+}
+
+func (sm StacksManager) lastCommit(branch string) (CommitReport, error) {
+	if sm.nativeGit != nil {
+		return sm.nativeGit.lastCommit(branch)
+	}
+	output, err := sm.gitExecutor.Exec("log", commitFormat, "-n", "1", branch)
+	if err != nil {
+		return CommitReport{}, errors.New("failed to get last commit\n" + output)
+	}
+	commits := parseCommits(output)
+	if len(commits) == 0 {
+		return CommitReport{}, errors.New("failed to get last commit\n" + output)
+	}
+	return commits[0], nil
+}
+
+// aheadBehind returns the number of commits in branch not in other (ahead)
+// and in other not in branch (behind). Merge commits are counted.
+func (sm StacksManager) aheadBehind(branch string, other string) (int, int, error) {
+	if sm.nativeGit != nil {
+		return sm.nativeGit.aheadBehind(branch, other)
+	}
+	output, err := sm.gitExecutor.Exec("rev-list", "--left-right", "--count", branch+"..."+other)
+	if err != nil {
+		return 0, 0, errors.New("failed to count commits\n" + output)
+	}
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, errors.New("failed to count commits\n" + output)
+	}
+	ahead, errAhead := strconv.Atoi(fields[0])
+	behind, errBehind := strconv.Atoi(fields[1])
+	if errAhead != nil || errBehind != nil {
+		return 0, 0, errors.New("failed to count commits\n" + output)
+	}
+	return ahead, behind, nil
 }
 
-func (sm StacksManager) commitsBetweenBranches(baseBranch string, nextBranch string) ([]string, error) {
-	output, err := sm.gitExecutor.Exec("log", "--no-merges", "--reverse", "--right-only", "--pretty=format:%h %s - %cr", baseBranch+"..."+nextBranch)
+func (sm StacksManager) commitsBetweenBranches(baseBranch string, nextBranch string) ([]CommitReport, error) {
+	if sm.nativeGit != nil {
+		return sm.nativeGit.commitsBetweenBranches(baseBranch, nextBranch)
+	}
+	output, err := sm.gitExecutor.Exec("log", "--no-merges", "--reverse", "--right-only", commitFormat, baseBranch+"..."+nextBranch)
 	if err != nil {
 		return nil, errors.New("failed to get commits log\n" + output)
 	}
-	return strings.Split(output, "\n"), nil
+	return parseCommits(output), nil
+}
+
+// commitFilter selects the commits of filteredCommitsBetweenBranches.
+type commitFilter struct {
+	author string
+	since  string
+	// paths are globs, e.g. internal/**
+	paths []string
+	// stat adds the stat of each commit
+	stat bool
+	// merges keeps the merge commits, e.g. the ones of sync
+	merges bool
+}
+
+func (f commitFilter) isEmpty() bool {
+	return f.author == "" && f.since == "" && len(f.paths) == 0 && !f.stat && !f.merges
+}
+
+// filteredCommitsBetweenBranches is commitsBetweenBranches with a filter, it always runs git.
+func (sm StacksManager) filteredCommitsBetweenBranches(baseBranch string, nextBranch string, filter commitFilter) ([]CommitReport, error) {
+	if filter.isEmpty() {
+		return sm.commitsBetweenBranches(baseBranch, nextBranch)
+	}
+
+	args := []string{"log", "--reverse", "--right-only", commitFormat}
+	if !filter.merges {
+		args = slices.Insert(args, 1, "--no-merges")
+	}
+	if filter.author != "" {
+		args = append(args, "--author="+filter.author)
+	}
+	if filter.since != "" {
+		args = append(args, "--since="+filter.since)
+	}
+	if filter.stat {
+		args = append(args, "--shortstat")
+	}
+	args = append(args, baseBranch+"..."+nextBranch)
+	args = append(args, pathspecs(filter.paths)...)
+	output, err := sm.gitExecutor.Exec(args...)
+	if err != nil {
+		return nil, errors.New("failed to get commits log\n" + output)
+	}
+	return parseCommits(output), nil
+}
+
+// lastSyncMerge is the most recent merge of sync in branch, nil if it was never synced.
+func (sm StacksManager) lastSyncMerge(parent string, branch string) (*CommitReport, error) {
+	output, err := sm.gitExecutor.Exec("log", "--merges", "--first-parent", "--fixed-strings", "--grep=(gostacking)", "-n", "1", commitFormat, parent+".."+branch)
+	if err != nil {
+		return nil, errors.New("failed to get the last sync\n" + output)
+	}
+	commits := parseCommits(output)
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	return &commits[0], nil
+}
+
+// diffStat is the stat of the changes of nextBranch since baseBranch, limited to the paths when given.
+func (sm StacksManager) diffStat(baseBranch string, nextBranch string, paths []string) (DiffStat, error) {
+	args := append([]string{"diff", "--shortstat", baseBranch + "..." + nextBranch}, pathspecs(paths)...)
+	output, err := sm.gitExecutor.Exec(args...)
+	if err != nil {
+		return DiffStat{}, errors.New("failed to get diff stat\n" + output)
+	}
+	return parseShortStat(output), nil
+}
+
+func pathspecs(globs []string) []string {
+	if len(globs) == 0 {
+		return nil
+	}
+	args := []string{"--"}
+	for _, glob := range globs {
+		args = append(args, ":(glob)"+glob)
+	}
+	return args
+}
+
+var shortStatPattern = regexp.MustCompile(`(\d+) (files? changed|insertions?\(\+\)|deletions?\(-\))`)
+
+// parseShortStat parses a --shortstat line, e.g. " 2 files changed, 5 insertions(+), 1 deletion(-)".
+func parseShortStat(line string) DiffStat {
+	var stat DiffStat
+	for _, match := range shortStatPattern.FindAllStringSubmatch(line, -1) {
+		count, _ := strconv.Atoi(match[1])
+		switch {
+		case strings.HasPrefix(match[2], "file"):
+			stat.FilesChanged = count
+		case strings.HasPrefix(match[2], "insertion"):
+			stat.Insertions = count
+		default:
+			stat.Deletions = count
+		}
+	}
+	return stat
+}
+
+// remote is the remote from the config, origin by default.
+func (sm StacksManager) remote() string {
+	if sm.config.Remote == "" {
+		return "origin"
+	}
+	return sm.config.Remote
 }
 
 func (sm StacksManager) githubRepoUrl() (string, error) {
-	remote, err := sm.gitExecutor.Exec("remote", "get-url", "origin")
+	remote, err := sm.gitExecutor.Exec("remote", "get-url", sm.remote())
 	if err != nil {
 		return "", errors.New("failed to get remote url")
 	}
 
-	if !strings.Contains(remote, "github") {
+	if !strings.Contains(remote, "github") && sm.config.Forge[remoteHost(remote)] != "github" {
 		return "", nil
 	}
 
@@ -123,3 +318,26 @@ func (sm StacksManager) githubRepoUrl() (string, error) {
 	remote = strings.Replace(remote, ".git", "", 1)
 	return remote, nil
 }
+
+// remoteHost is the host of a remote url like git@host:path or https://host/path.
+func remoteHost(url string) string {
+	_, host, found := strings.Cut(url, "://")
+	if !found {
+		host = url
+	}
+	host, _, _ = strings.Cut(host, "/")
+	if _, afterUser, found := strings.Cut(host, "@"); found {
+		host = afterUser
+	}
+	host, _, _ = strings.Cut(host, ":")
+	return host
+}
+
+// commitSha is empty when the branch does not exist.
+func (sm StacksManager) commitSha(branch string) string {
+	sha, err := sm.gitExecutor.Exec("rev-parse", branch)
+	if err != nil {
+		return ""
+	}
+	return sha
+}
@@ -0,0 +1,363 @@
+package stack
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BackendCli   = "cli"
+	BackendGoGit = "go-git"
+)
+
+// nativeGitReader implements the read operations with go-git instead of running git.
+// Writes (checkout, merge, push...) always use the git CLI.
+// It is selected with `git config gostacking.backend go-git`.
+type nativeGitReader struct {
+	// mutex guards the go-git storage, it is not safe for concurrent use (see statusReport).
+	// Only the object and reference reads hold it, the walks and the reports run concurrently.
+	mutex sync.Mutex
+	repo  *git.Repository
+	now   func() time.Time
+	// abbreviations of the commit hashes already reported, guarded by mutex
+	abbreviations map[plumbing.Hash]string
+}
+
+func newNativeGitReader(path string) (*nativeGitReader, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &nativeGitReader{repo: repo, now: time.Now}, nil
+}
+
+func (r *nativeGitReader) currentBranchName() (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return "", errors.New("failed to get current branch")
+	}
+	// Same as `git rev-parse --abbrev-ref HEAD` on a detached HEAD
+	if !head.Name().IsBranch() {
+		return "HEAD", nil
+	}
+	return head.Name().Short(), nil
+}
+
+func (r *nativeGitReader) branchExists(branchName string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, err := r.repo.ResolveRevision(plumbing.Revision(branchName))
+	return err == nil
+}
+
+func (r *nativeGitReader) defaultBranchWithRemote(remote string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ref, err := r.repo.Storer.Reference(plumbing.ReferenceName("refs/remotes/" + remote + "/HEAD"))
+	if err != nil || ref.Type() != plumbing.SymbolicReference {
+		return "", errors.New("Error getting " + remote + " default main branch:\n To set it try: " + color.Teal("git remote set-head "+remote+" <<main branch>>"))
+	}
+	return ref.Target().Short(), nil
+}
+
+func (r *nativeGitReader) lastCommit(branch string) (CommitReport, error) {
+	commit, err := r.commit(branch)
+	if err != nil {
+		return CommitReport{}, errors.New("failed to get last commit\n" + err.Error())
+	}
+	return r.commitReport(commit), nil
+}
+
+func (r *nativeGitReader) aheadBehind(branch string, other string) (int, int, error) {
+	left, right, err := r.symmetricDifference(branch, other)
+	if err != nil {
+		return 0, 0, errors.New("failed to count commits\n" + err.Error())
+	}
+	return len(left), len(right), nil
+}
+
+func (r *nativeGitReader) commitsBetweenBranches(baseBranch string, nextBranch string) ([]CommitReport, error) {
+	_, right, err := r.symmetricDifference(baseBranch, nextBranch)
+	if err != nil {
+		return nil, errors.New("failed to get commits log\n" + err.Error())
+	}
+
+	// Same as `git log --no-merges --reverse`
+	sort.SliceStable(right, func(i, j int) bool {
+		return right[i].Committer.When.Before(right[j].Committer.When)
+	})
+	var commits []CommitReport
+	for _, commit := range right {
+		if commit.NumParents() > 1 {
+			continue
+		}
+		commits = append(commits, r.commitReport(commit))
+	}
+	return commits, nil
+}
+
+func (r *nativeGitReader) commit(revision string) (*object.Commit, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, err
+	}
+	return r.repo.CommitObject(*hash)
+}
+
+func (r *nativeGitReader) commitReport(commit *object.Commit) CommitReport {
+	return CommitReport{
+		Hash:         r.abbreviate(commit.Hash),
+		Subject:      commitSubject(commit.Message),
+		Author:       commit.Author.Name,
+		Date:         commit.Committer.When.Format("2006-01-02T15:04:05-07:00"),
+		RelativeDate: relativeDate(commit.Committer.When, r.now()),
+	}
+}
+
+func (r *nativeGitReader) parents(commit *object.Commit) ([]*object.Commit, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var parents []*object.Commit
+	err := commit.Parents().ForEach(func(parent *object.Commit) error {
+		parents = append(parents, parent)
+		return nil
+	})
+	return parents, err
+}
+
+// minAbbreviation is the minimum length of the abbreviated hashes, like the default of core.abbrev.
+const minAbbreviation = 7
+
+// abbreviate shortens the hash like %h, to the shortest prefix of at least minAbbreviation
+// characters that no other object of the repository starts with.
+// Unlike git, the minimum doesn't grow with the number of objects of large repositories.
+func (r *nativeGitReader) abbreviate(hash plumbing.Hash) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if abbreviation, ok := r.abbreviations[hash]; ok {
+		return abbreviation
+	}
+	// The objects sharing the first minAbbreviation characters share these bytes
+	others, err := r.hashesWithPrefix(hash[:(minAbbreviation-1)/2])
+	if err != nil {
+		return hash.String()[:minAbbreviation]
+	}
+	abbreviation := abbreviateHash(hash, others)
+	if r.abbreviations == nil {
+		r.abbreviations = map[plumbing.Hash]string{}
+	}
+	r.abbreviations[hash] = abbreviation
+	return abbreviation
+}
+
+// hashesWithPrefix is fast with the filesystem storage, it goes through every object otherwise.
+func (r *nativeGitReader) hashesWithPrefix(prefix []byte) ([]plumbing.Hash, error) {
+	type prefixFinder interface {
+		HashesWithPrefix(prefix []byte) ([]plumbing.Hash, error)
+	}
+	if finder, ok := r.repo.Storer.(prefixFinder); ok {
+		return finder.HashesWithPrefix(prefix)
+	}
+
+	var hashes []plumbing.Hash
+	iter, err := r.repo.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return nil, err
+	}
+	err = iter.ForEach(func(obj plumbing.EncodedObject) error {
+		if hash := obj.Hash(); bytes.HasPrefix(hash[:], prefix) {
+			hashes = append(hashes, hash)
+		}
+		return nil
+	})
+	return hashes, err
+}
+
+// abbreviateHash is the shortest prefix of hash of at least minAbbreviation characters
+// that none of the others starts with.
+func abbreviateHash(hash plumbing.Hash, others []plumbing.Hash) string {
+	full := hash.String()
+	length := minAbbreviation
+	for _, other := range others {
+		if other == hash {
+			continue
+		}
+		otherFull := other.String()
+		common := 0
+		for common < len(full) && full[common] == otherFull[common] {
+			common++
+		}
+		length = max(length, min(common+1, len(full)))
+	}
+	return full[:length]
+}
+
+const (
+	reachableFromLeft = 1 << iota
+	reachableFromRight
+	reachableFromBoth = reachableFromLeft | reachableFromRight
+)
+
+// symmetricDifference returns the commits reachable only from left and only from right,
+// like `git rev-list left...right`. Both histories are walked from the most recent commit
+// and the walk stops when every remaining commit is reachable from both sides.
+// A commit already walked from one side only is walked again to flag its ancestors,
+// commits often have the same timestamp (e.g. rebased or scripted).
+func (r *nativeGitReader) symmetricDifference(leftRevision string, rightRevision string) ([]*object.Commit, []*object.Commit, error) {
+	left, err := r.commit(leftRevision)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", leftRevision, err)
+	}
+	right, err := r.commit(rightRevision)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", rightRevision, err)
+	}
+
+	flags := map[plumbing.Hash]int{}
+	walked := map[plumbing.Hash]int{}
+	queue := &commitQueue{}
+	flags[left.Hash] |= reachableFromLeft
+	flags[right.Hash] |= reachableFromRight
+	heap.Push(queue, left)
+	heap.Push(queue, right)
+
+	var visited []*object.Commit
+	for queue.Len() > 0 && !queue.settled(flags, walked) {
+		commit := heap.Pop(queue).(*object.Commit)
+		flag := flags[commit.Hash]
+		if walked[commit.Hash] == flag {
+			continue
+		}
+		if walked[commit.Hash] == 0 {
+			visited = append(visited, commit)
+		}
+		walked[commit.Hash] = flag
+
+		parents, err := r.parents(commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, parent := range parents {
+			if flags[parent.Hash]&flag != flag {
+				flags[parent.Hash] |= flag
+				heap.Push(queue, parent)
+			}
+		}
+	}
+
+	var leftOnly, rightOnly []*object.Commit
+	for _, commit := range visited {
+		switch flags[commit.Hash] {
+		case reachableFromLeft:
+			leftOnly = append(leftOnly, commit)
+		case reachableFromRight:
+			rightOnly = append(rightOnly, commit)
+		}
+	}
+	return leftOnly, rightOnly, nil
+}
+
+// commitQueue is a priority queue of commits, the most recent first.
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+func (q commitQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*object.Commit)) }
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// settled is true when every queued commit is reachable from both sides
+// and none of them still has to flag ancestors walked from one side only.
+func (q commitQueue) settled(flags map[plumbing.Hash]int, walked map[plumbing.Hash]int) bool {
+	for _, commit := range q {
+		if flags[commit.Hash] != reachableFromBoth {
+			return false
+		}
+		if walked[commit.Hash] != 0 && walked[commit.Hash] != reachableFromBoth {
+			return false
+		}
+	}
+	return true
+}
+
+// commitSubject is the first paragraph of the message on a single line, like %s.
+func commitSubject(message string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+	return strings.Join(strings.Fields(strings.ReplaceAll(paragraph, "\n", " ")), " ")
+}
+
+// relativeDate formats the date like git does for %cr (e.g. 3 minutes ago).
+func relativeDate(date time.Time, now time.Time) string {
+	diff := int(now.Sub(date).Seconds())
+	if diff < 0 {
+		return "in the future"
+	}
+	if diff < 90 {
+		return plural(diff, "second") + " ago"
+	}
+	diff = (diff + 30) / 60
+	if diff < 90 {
+		return plural(diff, "minute") + " ago"
+	}
+	diff = (diff + 30) / 60
+	if diff < 36 {
+		return plural(diff, "hour") + " ago"
+	}
+	diff = (diff + 12) / 24
+	if diff < 14 {
+		return plural(diff, "day") + " ago"
+	}
+	if diff < 70 {
+		return plural((diff+3)/7, "week") + " ago"
+	}
+	if diff < 365 {
+		return plural((diff+15)/30, "month") + " ago"
+	}
+	if diff < 1825 {
+		totalMonths := (diff*12*2 + 365) / (365 * 2)
+		years := totalMonths / 12
+		months := totalMonths % 12
+		if months > 0 {
+			return plural(years, "year") + ", " + plural(months, "month") + " ago"
+		}
+		return plural(years, "year") + " ago"
+	}
+	return plural((diff+183)/365, "year") + " ago"
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
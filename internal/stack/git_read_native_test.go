@@ -0,0 +1,311 @@
+package stack
+
+import (
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var nativeTestStart = time.Date(2024, 1, 1, 10, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+type nativeTestRepo struct {
+	t       *testing.T
+	storage *memory.Storage
+	reader  *nativeGitReader
+	minutes int
+}
+
+func newNativeTestRepo(t *testing.T) *nativeTestRepo {
+	storage := memory.NewStorage()
+	repo, err := git.Open(storage, nil)
+	if err != nil {
+		repo, err = git.Init(storage, nil)
+	}
+	if err != nil {
+		t.Fatalf("failed to create repository: %s", err)
+	}
+	return &nativeTestRepo{
+		t:       t,
+		storage: storage,
+		reader: &nativeGitReader{
+			repo: repo,
+			now:  func() time.Time { return nativeTestStart.Add(3 * time.Hour) },
+		},
+	}
+}
+
+// commit stores a commit one minute after the previous one.
+func (r *nativeTestRepo) commit(message string, parents ...plumbing.Hash) plumbing.Hash {
+	r.minutes++
+	signature := object.Signature{
+		Name:  "John Doe",
+		Email: "john@example.com",
+		When:  nativeTestStart.Add(time.Duration(r.minutes) * time.Minute),
+	}
+
+	treeObject := r.storage.NewEncodedObject()
+	err := (&object.Tree{}).Encode(treeObject)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	treeHash, err := r.storage.SetEncodedObject(treeObject)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	commit := &object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+	commitObject := r.storage.NewEncodedObject()
+	err = commit.Encode(commitObject)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	hash, err := r.storage.SetEncodedObject(commitObject)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash
+}
+
+func (r *nativeTestRepo) setReference(ref *plumbing.Reference) {
+	err := r.storage.SetReference(ref)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func (r *nativeTestRepo) branch(name string, hash plumbing.Hash) {
+	r.setReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash))
+}
+
+// stackedRepo creates:
+//
+//	main:     c1 - c2 ------- c3 ------------- c4
+//	                \           \
+//	feature1:        f1 - f2 - merge
+//	                              \
+//	feature2:                      f3
+func stackedRepo(t *testing.T) *nativeTestRepo {
+	repo := newNativeTestRepo(t)
+	c1 := repo.commit("c1")
+	c2 := repo.commit("c2", c1)
+	f1 := repo.commit("Add feature\n\nWith a description", c2)
+	f2 := repo.commit("Fix\nfeature", f1)
+	c3 := repo.commit("c3", c2)
+	merge := repo.commit("Merge branch main into feature1 (gostacking)", f2, c3)
+	f3 := repo.commit("f3", merge)
+	c4 := repo.commit("c4", c3)
+
+	repo.branch("main", c4)
+	repo.branch("feature1", merge)
+	repo.branch("feature2", f3)
+	repo.setReference(plumbing.NewHashReference("refs/remotes/origin/main", c3))
+	repo.setReference(plumbing.NewSymbolicReference("refs/remotes/origin/HEAD", "refs/remotes/origin/main"))
+	repo.setReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("feature2")))
+	return repo
+}
+
+func TestNativeGitReader_currentBranchName(t *testing.T) {
+	t.Run("on a branch", func(t *testing.T) {
+		repo := stackedRepo(t)
+
+		got, err := repo.reader.currentBranchName()
+		if err != nil || got != "feature2" {
+			t.Errorf("got %s %v, want %s", got, err, "feature2")
+		}
+	})
+
+	t.Run("on a detached HEAD", func(t *testing.T) {
+		repo := stackedRepo(t)
+		repo.setReference(plumbing.NewHashReference(plumbing.HEAD, repo.commit("detached")))
+
+		got, err := repo.reader.currentBranchName()
+		if err != nil || got != "HEAD" {
+			t.Errorf("got %s %v, want %s", got, err, "HEAD")
+		}
+	})
+}
+
+func TestNativeGitReader_branchExists(t *testing.T) {
+	repo := stackedRepo(t)
+
+	if !repo.reader.branchExists("feature1") {
+		t.Errorf("feature1 should exist")
+	}
+	if !repo.reader.branchExists("origin/main") {
+		t.Errorf("origin/main should exist")
+	}
+	if repo.reader.branchExists("non_existing_branch") {
+		t.Errorf("non_existing_branch should not exist")
+	}
+}
+
+func TestNativeGitReader_defaultBranchWithRemote(t *testing.T) {
+	t.Run("when origin/HEAD is set", func(t *testing.T) {
+		repo := stackedRepo(t)
+
+		got, err := repo.reader.defaultBranchWithRemote("origin")
+		if err != nil || got != "origin/main" {
+			t.Errorf("got %s %v, want %s", got, err, "origin/main")
+		}
+	})
+
+	t.Run("when origin/HEAD is not set", func(t *testing.T) {
+		repo := newNativeTestRepo(t)
+
+		_, err := repo.reader.defaultBranchWithRemote("origin")
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
+
+func TestNativeGitReader_aheadBehind(t *testing.T) {
+	repo := stackedRepo(t)
+
+	tests := []struct {
+		branch string
+		other  string
+		ahead  int
+		behind int
+	}{
+		// f1, f2 and the merge commit
+		{"feature1", "origin/main", 3, 0},
+		{"feature1", "main", 3, 1},
+		{"feature2", "feature1", 1, 0},
+		{"feature1", "feature2", 0, 1},
+		{"feature2", "main", 4, 1},
+		{"main", "main", 0, 0},
+	}
+	for _, test := range tests {
+		ahead, behind, err := repo.reader.aheadBehind(test.branch, test.other)
+		if err != nil {
+			t.Errorf("%s...%s: show have no error, got %s", test.branch, test.other, err)
+		}
+		if ahead != test.ahead || behind != test.behind {
+			t.Errorf("%s...%s: got %d %d, want %d %d", test.branch, test.other, ahead, behind, test.ahead, test.behind)
+		}
+	}
+
+	_, _, err := repo.reader.aheadBehind("feature1", "origin/feature1")
+	if err == nil {
+		t.Errorf("got none, want Error")
+	}
+}
+
+func TestNativeGitReader_aheadBehindWithClockSkew(t *testing.T) {
+	// a - x - left
+	//      \
+	//       right
+	// a is more recent than its child x and right is the oldest,
+	// so a is walked from left only before right reaches x.
+	repo := newNativeTestRepo(t)
+	repo.minutes = 4
+	a := repo.commit("a")
+	repo.minutes = 1
+	x := repo.commit("x", a)
+	repo.minutes = 9
+	repo.branch("left", repo.commit("left", x))
+	repo.minutes = 0
+	repo.branch("right", repo.commit("right", x))
+
+	ahead, behind, err := repo.reader.aheadBehind("left", "right")
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+	if ahead != 1 || behind != 1 {
+		t.Errorf("got %d %d, want 1 1", ahead, behind)
+	}
+}
+
+func TestNativeGitReader_commitsBetweenBranches(t *testing.T) {
+	repo := stackedRepo(t)
+
+	commits, err := repo.reader.commitsBetweenBranches("origin/main", "feature1")
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+
+	var subjects []string
+	for _, commit := range commits {
+		subjects = append(subjects, commit.Subject)
+	}
+	want := []string{"Add feature", "Fix feature"}
+	if !reflect.DeepEqual(subjects, want) {
+		t.Errorf("got %s, want %s", subjects, want)
+	}
+}
+
+func TestNativeGitReader_lastCommit(t *testing.T) {
+	repo := stackedRepo(t)
+
+	got, err := repo.reader.lastCommit("feature2")
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+
+	hash, _ := repo.reader.repo.ResolveRevision("feature2")
+	want := CommitReport{
+		Hash:         hash.String()[:7],
+		Subject:      "f3",
+		Author:       "John Doe",
+		Date:         "2024-01-01T10:07:00-05:00",
+		RelativeDate: "3 hours ago",
+	}
+	if got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRelativeDate(t *testing.T) {
+	now := nativeTestStart
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{1 * time.Second, "1 second ago"},
+		{89 * time.Second, "89 seconds ago"},
+		{3 * time.Minute, "3 minutes ago"},
+		{2 * time.Hour, "2 hours ago"},
+		{3 * 24 * time.Hour, "3 days ago"},
+		{21 * 24 * time.Hour, "3 weeks ago"},
+		{100 * 24 * time.Hour, "3 months ago"},
+		{400 * 24 * time.Hour, "1 year, 1 month ago"},
+		{365 * 24 * time.Hour, "1 year ago"},
+		{3000 * 24 * time.Hour, "8 years ago"},
+	}
+	for _, test := range tests {
+		got := relativeDate(now.Add(-test.ago), now)
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.ago, got, test.want)
+		}
+	}
+}
+
+func TestAbbreviateHash(t *testing.T) {
+	hash := plumbing.NewHash("abcdef1234567890abcdef1234567890abcdef12")
+	tests := []struct {
+		others []plumbing.Hash
+		want   string
+	}{
+		{nil, "abcdef1"},
+		{[]plumbing.Hash{hash, plumbing.NewHash("abcdef0000000000000000000000000000000000")}, "abcdef1"},
+		{[]plumbing.Hash{plumbing.NewHash("abcdef1200000000000000000000000000000000")}, "abcdef123"},
+		{[]plumbing.Hash{plumbing.NewHash("abcdef1000000000000000000000000000000000"), plumbing.NewHash("abcdef1234000000000000000000000000000000")}, "abcdef12345"},
+	}
+	for _, test := range tests {
+		got := abbreviateHash(hash, test.others)
+		if got != test.want {
+			t.Errorf("%v: got %s, want %s", test.others, got, test.want)
+		}
+	}
+}
@@ -27,13 +27,26 @@ func (sm StacksManager) pushBranch() error {
 // pullBranch will pull the current branch from the remote
 // If the branch does not have a remote, it will NOT return an error
 func (sm StacksManager) pullBranch() error {
-	output, err := sm.gitExecutor.Exec("pull")
+	output, err := sm.gitExecutor.Exec(sm.pullCommand()...)
 	if err != nil && !strings.Contains(output, "There is no tracking information") {
 		return errors.New("failed to pull\n" + output)
 	}
 	return nil
 }
 
+// pullCommand follows the sync.strategy config, auto lets git pull as configured.
+func (sm StacksManager) pullCommand() []string {
+	switch sm.config.Sync.Strategy {
+	case "merge":
+		return []string{"pull", "--no-rebase"}
+	case "rebase":
+		return []string{"pull", "--rebase"}
+	case "ff-only":
+		return []string{"pull", "--ff-only"}
+	}
+	return []string{"pull"}
+}
+
 func (sm StacksManager) merge(currentBranch string, parentBranch string) error {
 	output, err := sm.gitExecutor.Exec(
 		"merge",
@@ -48,9 +61,67 @@ func (sm StacksManager) merge(currentBranch string, parentBranch string) error {
 }
 
 func (sm StacksManager) publishBranch(branchName string) error {
-	output, err := sm.gitExecutor.Exec("push", "-u", "origin", branchName)
+	output, err := sm.gitExecutor.Exec("push", "-u", sm.remote(), branchName)
 	if err != nil {
 		return errors.New("failed to publish branch\n" + output)
 	}
 	return nil
 }
+
+func (sm StacksManager) renameBranch(oldName string, newName string) error {
+	output, err := sm.gitExecutor.Exec("branch", "-m", oldName, newName)
+	if err != nil {
+		return errors.New("failed to rename " + color.Yellow(oldName) + " to " + color.Yellow(newName) + "\n" + output)
+	}
+	return nil
+}
+
+func (sm StacksManager) deleteRemoteBranch(branchName string) error {
+	output, err := sm.gitExecutor.Exec("push", sm.remote(), "--delete", branchName)
+	if err != nil {
+		return errors.New("failed to delete remote branch " + color.Yellow(branchName) + "\n" + output)
+	}
+	return nil
+}
+
+func (sm StacksManager) setUpstream(branchName string) error {
+	output, err := sm.gitExecutor.Exec("branch", "--set-upstream-to", sm.remote()+"/"+branchName, branchName)
+	if err != nil {
+		return errors.New("failed to set upstream of " + color.Yellow(branchName) + "\n" + output)
+	}
+	return nil
+}
+
+func (sm StacksManager) setRemoteHead() error {
+	output, err := sm.gitExecutor.Exec("remote", "set-head", sm.remote(), "--auto")
+	if err != nil {
+		return errors.New("failed to set " + sm.remote() + "/HEAD\n" + output)
+	}
+	return nil
+}
+
+// addWorktree checks out branch at path with a detached HEAD
+// since a branch can't be checked out in two worktrees.
+func (sm StacksManager) addWorktree(path string, branchName string) error {
+	output, err := sm.gitExecutor.Exec("worktree", "add", "--detach", path, branchName)
+	if err != nil {
+		return errors.New("failed to create a worktree for " + color.Yellow(branchName) + "\n" + output)
+	}
+	return nil
+}
+
+func (sm StacksManager) removeWorktree(path string) error {
+	output, err := sm.gitExecutor.Exec("worktree", "remove", "--force", path)
+	if err != nil {
+		return errors.New("failed to remove worktree " + path + "\n" + output)
+	}
+	return nil
+}
+
+func (sm StacksManager) pruneWorktrees() error {
+	output, err := sm.gitExecutor.Exec("worktree", "prune")
+	if err != nil {
+		return errors.New("failed to prune worktrees\n" + output)
+	}
+	return nil
+}
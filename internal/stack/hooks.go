@@ -0,0 +1,107 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/cliexec"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	HookPreSync    = "pre-sync"
+	HookPostMerge  = "post-merge"
+	HookPostSync   = "post-sync"
+	HookPrePublish = "pre-publish"
+	HookPostAdd    = "post-add"
+)
+
+// hookFinder finds the command of a hook and the directory where it runs.
+type hookFinder interface {
+	command(name string) (dir string, command []string, found bool)
+}
+
+// repoHooks are configured with `git config gostacking.hook.<name> <script>`
+// or as an executable file .gostacking/hooks/<name> in the repository.
+// The git config has precedence so a user can override a hook of the team.
+type repoHooks struct {
+	gitExecutor cliexec.InterfaceCliExecutor
+}
+
+func (h repoHooks) command(name string) (string, []string, bool) {
+	toplevel, err := h.gitExecutor.Exec("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", nil, false
+	}
+
+	script, err := h.gitExecutor.Exec("config", "--get", "gostacking.hook."+name)
+	if err == nil && script != "" {
+		return toplevel, shellCommand(script), true
+	}
+
+	path := filepath.Join(toplevel, ".gostacking", "hooks", name)
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+		return toplevel, []string{path}, true
+	}
+	return "", nil, false
+}
+
+// runHook runs the hook name for the branch at index i of the stack, or for the
+// whole stack when i is negative. The hook gets the variables of branchEnv with
+// GOSTACKING_HOOK, GOSTACKING_BRANCH_SHA and GOSTACKING_PARENT_SHA.
+func (sm StacksManager) runHook(name string, stackName string, branches []string, i int) error {
+	parent := ""
+	if i > 0 {
+		parent = branches[i-1]
+	}
+	return sm.runHookWithParent(name, stackName, branches, i, parent)
+}
+
+// runHookWithParent is runHook where the parent of the branch is not the previous one,
+// the variables of the parent are not set when it is empty.
+func (sm StacksManager) runHookWithParent(name string, stackName string, branches []string, i int, parent string) error {
+	if sm.hooks == nil {
+		return nil
+	}
+	dir, command, found := sm.hooks.command(name)
+	if !found {
+		return nil
+	}
+
+	env := []string{"GOSTACKING_HOOK=" + name, "GOSTACKING_STACK=" + stackName}
+	if i >= 0 {
+		env = append(branchEnvWithParent(stackName, branches, i, parent), "GOSTACKING_HOOK="+name, "GOSTACKING_BRANCH_SHA="+sm.commitSha(branches[i]))
+		if parent != "" {
+			env = append(env, "GOSTACKING_PARENT_SHA="+sm.commitSha(parent))
+		}
+	}
+
+	sm.printer.Println("\tHook", color.Magenta(name)+"...")
+	output, err := sm.runner.Run(dir, env, command...)
+	if output != "" {
+		sm.printer.Println("\t\t" + strings.ReplaceAll(output, "\n", "\n\t\t"))
+	}
+	if err != nil {
+		return errors.New("hook " + color.Magenta(name) + " failed\n" + err.Error())
+	}
+	return nil
+}
+
+// runPostHook runs a hook after an operation that is already done,
+// so a failure is only reported.
+func (sm StacksManager) runPostHook(name string, stackName string, branches []string, i int) {
+	err := sm.runHook(name, stackName, branches, i)
+	if err != nil {
+		sm.printer.Println(color.Red("Warning:"), err.Error())
+	}
+}
+
+// runPostMergeHook runs the post-merge hook of the branch at index i once parent is merged in it.
+func (sm StacksManager) runPostMergeHook(stackName string, branches []string, i int, parent string) {
+	err := sm.runHookWithParent(HookPostMerge, stackName, branches, i, parent)
+	if err != nil {
+		sm.printer.Println(color.Red("Warning:"), err.Error())
+	}
+}
@@ -0,0 +1,263 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type hookFinderStub struct {
+	commands map[string][]string
+}
+
+func (h hookFinderStub) command(name string) (string, []string, bool) {
+	command, found := h.commands[name]
+	return "/repo", command, found
+}
+
+type hookRun struct {
+	command []string
+	env     []string
+}
+
+// hooksStacksManager runs the hooks configured with commands through a runner
+// that fails when the command is "false".
+func hooksStacksManager(gitExecutor cliExecutorStub, commands map[string][]string, messageReceived *[]string) (StacksManager, *[]hookRun) {
+	var runs []hookRun
+	stacksManager := StacksManagerForTest(gitExecutor, messageReceived)
+	stacksManager.hooks = hookFinderStub{commands: commands}
+	stacksManager.runner = commandRunnerStub{
+		stubRun: func(dir string, env []string, command ...string) (string, error) {
+			runs = append(runs, hookRun{command: command, env: env})
+			if command[0] == "false" {
+				return "lint failed", errors.New("exit status 1")
+			}
+			return "", nil
+		},
+	}
+	return stacksManager, &runs
+}
+
+func TestRepoHooks_command(t *testing.T) {
+	toplevel := t.TempDir()
+	hooksDir := filepath.Join(toplevel, ".gostacking", "hooks")
+	_ = os.MkdirAll(hooksDir, 0755)
+	_ = os.WriteFile(filepath.Join(hooksDir, HookPostMerge), []byte("#!/bin/sh\nnpm install\n"), 0755)
+	_ = os.WriteFile(filepath.Join(hooksDir, HookPostAdd), []byte("not executable"), 0644)
+
+	hooks := repoHooks{
+		gitExecutor: cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				if joinedCommand == "rev-parse --show-toplevel" {
+					return toplevel, nil
+				}
+				if joinedCommand == "config --get gostacking.hook.pre-publish" {
+					return "make lint", nil
+				}
+				return "", errors.New("exit status 1")
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		command []string
+		found   bool
+	}{
+		{HookPrePublish, []string{"sh", "-c", "make lint"}, true},
+		{HookPostMerge, []string{filepath.Join(hooksDir, HookPostMerge)}, true},
+		{HookPostAdd, nil, false},
+		{HookPreSync, nil, false},
+	}
+	for _, test := range tests {
+		dir, command, found := hooks.command(test.name)
+		if found != test.found || !reflect.DeepEqual(command, test.command) {
+			t.Errorf("%s: got %v %v, want %v %v", test.name, command, found, test.command, test.found)
+		}
+		if found && dir != toplevel {
+			t.Errorf("%s: got %s, want %s", test.name, dir, toplevel)
+		}
+	}
+}
+
+func TestStacksManager_runHook(t *testing.T) {
+	t.Run("sync hooks", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "rev-parse" && command[1] != "--abbrev-ref" {
+					return "sha-" + command[1], nil
+				}
+				return "", nil
+			},
+		}
+		commands := map[string][]string{
+			HookPreSync:   {"true"},
+			HookPostMerge: {"npm", "install"},
+			HookPostSync:  {"true"},
+		}
+		var messageReceived []string
+		stacksManager, runs := hooksStacksManager(gitExecutor, commands, &messageReceived)
+
+		err := stacksManager.Sync(false, false)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if len(*runs) != 3 {
+			t.Fatalf("got %v, want pre-sync, post-merge and post-sync", *runs)
+		}
+		wantEnv := []string{
+			"GOSTACKING_STACK=stack1",
+			"GOSTACKING_BRANCH=branch2",
+			"GOSTACKING_PARENT=branch1",
+			"GOSTACKING_POSITION=2",
+			"GOSTACKING_HOOK=post-merge",
+			"GOSTACKING_BRANCH_SHA=sha-branch2",
+			"GOSTACKING_PARENT_SHA=sha-branch1",
+		}
+		if !reflect.DeepEqual((*runs)[1].env, wantEnv) {
+			t.Errorf("got %v, want %v", (*runs)[1].env, wantEnv)
+		}
+		wantEnv = []string{"GOSTACKING_HOOK=post-sync", "GOSTACKING_STACK=stack1"}
+		if !reflect.DeepEqual((*runs)[2].env, wantEnv) {
+			t.Errorf("got %v, want %v", (*runs)[2].env, wantEnv)
+		}
+	})
+
+	t.Run("post-merge of the first branch with the default branch", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "symbolic-ref" {
+					return "origin/main", nil
+				}
+				if command[0] == "rev-parse" && command[1] != "--abbrev-ref" {
+					return "sha-" + command[1], nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, runs := hooksStacksManager(gitExecutor, map[string][]string{HookPostMerge: {"npm", "install"}}, &messageReceived)
+
+		err := stacksManager.Sync(false, true)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if len(*runs) != 2 {
+			t.Fatalf("got %v, want post-merge of branch1 and branch2", *runs)
+		}
+		wantEnv := []string{
+			"GOSTACKING_STACK=stack1",
+			"GOSTACKING_BRANCH=branch1",
+			"GOSTACKING_PARENT=origin/main",
+			"GOSTACKING_POSITION=1",
+			"GOSTACKING_HOOK=post-merge",
+			"GOSTACKING_BRANCH_SHA=sha-branch1",
+			"GOSTACKING_PARENT_SHA=sha-origin/main",
+		}
+		if !reflect.DeepEqual((*runs)[0].env, wantEnv) {
+			t.Errorf("got %v, want %v", (*runs)[0].env, wantEnv)
+		}
+	})
+
+	t.Run("when pre-sync fails", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "fetch" || command[0] == "checkout" {
+					t.Errorf("sync should have been aborted, got %s", command)
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, _ := hooksStacksManager(gitExecutor, map[string][]string{HookPreSync: {"false"}}, &messageReceived)
+
+		err := stacksManager.Sync(false, false)
+
+		if err == nil {
+			t.Fatalf("got none, want Error")
+		}
+		want := "hook " + color.Magenta("pre-sync") + " failed"
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", err.Error(), want)
+		}
+		if !strings.Contains(stacksManager.printerMessage(), "\t\tlint failed") {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), "\t\tlint failed")
+		}
+	})
+
+	t.Run("when post-merge fails", func(t *testing.T) {
+		var pushed bool
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "push" {
+					pushed = true
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, _ := hooksStacksManager(gitExecutor, map[string][]string{HookPostMerge: {"false"}}, &messageReceived)
+
+		err := stacksManager.Sync(true, false)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if !pushed {
+			t.Errorf("sync should continue after a post hook failure")
+		}
+		want := color.Red("Warning:") + " hook " + color.Magenta("post-merge") + " failed"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("when pre-publish fails", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "push" {
+					t.Errorf("publish should have been aborted")
+				}
+				return "branch2", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, runs := hooksStacksManager(gitExecutor, map[string][]string{HookPrePublish: {"false"}}, &messageReceived)
+
+		err := stacksManager.Publish()
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+		if len(*runs) != 1 || !slices.Contains((*runs)[0].env, "GOSTACKING_PARENT=branch1") {
+			t.Errorf("got %v, want pre-publish of branch2", *runs)
+		}
+	})
+
+	t.Run("post-add", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, runs := hooksStacksManager(gitExecutor, map[string][]string{HookPostAdd: {"true"}}, &messageReceived)
+
+		err := stacksManager.AddBranch("branch5", 1)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if len(*runs) != 1 || !slices.Contains((*runs)[0].env, "GOSTACKING_POSITION=1") {
+			t.Errorf("got %v, want post-add of branch5 at position 1", *runs)
+		}
+	})
+}
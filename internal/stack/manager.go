@@ -1,11 +1,20 @@
 package stack
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/Bhacaz/gostacking/internal/cliexec"
 	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/config"
+	"github.com/Bhacaz/gostacking/internal/editor"
+	"github.com/Bhacaz/gostacking/internal/pager"
 	"github.com/Bhacaz/gostacking/internal/printer"
+	"github.com/Bhacaz/gostacking/internal/prompt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 )
@@ -15,17 +24,97 @@ type StacksManager struct {
 	gitExecutor cliexec.InterfaceCliExecutor
 	ghExecutor cliexec.InterfaceCliExecutor
 	printer     printer.Printer
+	prompter    prompt.Prompter
+	editor      editor.Editor
+	pager       pager.Pager
+	output      string
+	cache       *repoCache
+	nativeGit   *nativeGitReader
+	config      config.Config
+	configFile  string
+	ctx         context.Context
+	runner      cliexec.InterfaceCommandRunner
+	hooks       hookFinder
 }
 
-func NewManager(cliVerbose bool) StacksManager {
-	return StacksManager{
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+type Options struct {
+	// Logger receives every git and gh command run under the hood, nil discards them
+	Logger *slog.Logger
+	// Output is the format of status, list and tree (OutputText or OutputJSON)
+	Output string
+	// Context cancels the running commands when done, e.g. on Ctrl-C
+	Context context.Context
+	// Timeouts of each git and gh command, defaults to cliexec.DefaultTimeouts
+	Timeouts cliexec.Timeouts
+	// Dir is the repository where the commands run, the current directory when empty
+	Dir string
+	// Out is where the messages are printed, os.Stdout when nil (then diff uses the pager)
+	Out io.Writer
+	// Config is loaded by the caller (see config.Loader), the defaults when nil
+	Config *config.Config
+	// UserConfigFile is the user layer of the config, config.DefaultUserFile() when empty
+	UserConfigFile string
+	// RecordFile is where the git and gh commands are recorded as a fixture for tests
+	RecordFile string
+}
+
+func NewManager(options Options) StacksManager {
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	out := options.Out
+	var outPager pager.Pager
+	if out == nil {
+		out = os.Stdout
+		outPager = pager.NewPager()
+	}
+
+	manager := StacksManager{
 		stacks: &StacksData{
-			StacksPersister: StacksPersistingFile{},
+			StacksPersister: StacksPersistingFile{Dir: options.Dir},
 		},
-		printer:     printer.NewPrinter(),
-		gitExecutor: cliexec.NewExecutor("git", cliVerbose),
-		ghExecutor: cliexec.NewExecutor("gh", cliVerbose),
+		printer:     printer.NewPrinterTo(out),
+		prompter:    prompt.NewPrompter(),
+		editor:      editor.NewEditor(),
+		pager:       outPager,
+		gitExecutor: cliexec.NewExecutor("git", options.Logger).WithContext(ctx).WithTimeouts(options.Timeouts).WithDir(options.Dir),
+		ghExecutor: cliexec.NewExecutor("gh", options.Logger).WithContext(ctx).WithTimeouts(options.Timeouts).WithDir(options.Dir),
+		output:      options.Output,
+		cache:       newRepoCache(),
+		ctx:         ctx,
+		runner:      cliexec.NewCommandRunner(ctx, options.Logger),
+	}
+	manager.hooks = repoHooks{gitExecutor: manager.gitExecutor}
+
+	if options.RecordFile != "" {
+		recorder := cliexec.NewRecorder(options.RecordFile)
+		manager.gitExecutor = recorder.Wrap("git", manager.gitExecutor)
+		manager.ghExecutor = recorder.Wrap("gh", manager.ghExecutor)
+	}
+
+	manager.configFile = options.UserConfigFile
+	if manager.configFile == "" {
+		manager.configFile = config.DefaultUserFile()
+	}
+	manager.config = config.Defaults()
+	if options.Config != nil {
+		manager.config = *options.Config
+	}
+
+	if manager.config.Backend == BackendGoGit {
+		nativeGit, err := newNativeGitReader(filepath.Join(options.Dir, "."))
+		if err == nil {
+			manager.nativeGit = nativeGit
+		}
 	}
+	return manager
 }
 
 func (sm StacksManager) CreateStack(stackName string) error {
@@ -58,37 +147,25 @@ func (sm StacksManager) CurrentStackStatus(showLog bool) error {
 		return err
 	}
 
-	var displayBranches string
-	branches, _ := data.GetBranchesByName(data.CurrentStack)
-	for i, branch := range branches {
-		branchStatus := defaultBranchStatus()
-
-		if sm.isBehindRemote(branch) {
-			branchStatus.BehindRemote = true
-		}
-		if sm.aheadRemote(branch) {
-			branchStatus.AheadRemote = true
-		}
-
-		displayBranches += fmt.Sprintf("%d. "+color.Yellow(branch), i+1)
-		if i == 0 {
-			branchStatus.BehindDefaultBranch = sm.behindDefaultBranch(branch)
-		} else {
-			hasDiff, _ := sm.branchHasDiff(branches[i-1], branch)
-			if hasDiff {
-				branchStatus.HasDiff = true
-			}
-		}
+	if sm.output == OutputJSON {
+		return sm.printJSON(sm.statusReport(true))
+	}
 
-		displayBranches += branchStatus.Symbols()
+	var displayBranches string
+	for _, branch := range sm.statusReport(false).Branches {
+		displayBranches += fmt.Sprintf("%d. "+color.Yellow(branch.Name), branch.Position)
+		displayBranches += branch.Status.Symbols()
 
 		if showLog {
-			displayBranches += "\n\t" + sm.lastLog(branch)
+			displayBranches += "\n\t" + sm.lastLog(branch.Name)
 		}
 
 		displayBranches += "\n"
 	}
 	sm.printer.Println("Current stack: " + color.Green(data.CurrentStack) + "\nBranches:\n" + displayBranches)
+	if data.InterruptedSync != nil && data.InterruptedSync.Stack == data.CurrentStack {
+		sm.printer.Println(color.Red("Last sync was interrupted at branch "+data.InterruptedSync.Branch) + ", run `" + color.Magenta("gostacking sync") + "` to finish it")
+	}
 	return nil
 }
 
@@ -137,12 +214,17 @@ func (sm StacksManager) AddBranch(branchName string, position int) error {
 
 	data.SaveStacks()
 	sm.printer.Println("Branch", color.Yellow(branchName), "added to", color.Green(data.CurrentStack))
+	sm.runPostHook(HookPostAdd, data.CurrentStack, stack.Branches, slices.Index(stack.Branches, branchName))
 	return nil
 }
 
 func (sm StacksManager) List() error {
 	sm.stacks.LoadStacks()
 	data := *sm.stacks
+	if sm.output == OutputJSON {
+		return sm.printJSON(sm.listReport())
+	}
+
 	sm.printer.Println("Current stack:", color.Green(data.CurrentStack))
 	for i, stack := range data.Stacks {
 		sm.printer.Println(
@@ -253,6 +335,103 @@ func (sm StacksManager) RemoveByNumber(number int) error {
 	return nil
 }
 
+func (sm StacksManager) MoveByName(branchName string, position int) error {
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	stack, _ := data.GetStackByName(data.CurrentStack)
+	index := slices.Index(stack.Branches, branchName)
+	if index == -1 {
+		sm.printer.Println("Branch", color.Yellow(branchName), "does not exist")
+		return nil
+	}
+
+	return sm.moveBranch(stack, index, position)
+}
+
+func (sm StacksManager) MoveByNumber(number int, position int) error {
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	stack, _ := data.GetStackByName(data.CurrentStack)
+	if number < 1 || number > len(stack.Branches) {
+		sm.printer.Println("Invalid branch number")
+		return nil
+	}
+
+	return sm.moveBranch(stack, number-1, position)
+}
+
+// moveBranch moves the branch at index to the given position (starting at 1)
+// and warns about parents that already contain commits of their new child.
+func (sm StacksManager) moveBranch(stack *Stack, index int, position int) error {
+	if position < 1 || position > len(stack.Branches) {
+		sm.printer.Println("Invalid position:", position)
+		return nil
+	}
+
+	branchName := stack.Branches[index]
+	if index == position-1 {
+		sm.printer.Println("Branch", color.Yellow(branchName), "is already at position", position)
+		return nil
+	}
+
+	newBranches := slices.Delete(slices.Clone(stack.Branches), index, index+1)
+	newBranches = slices.Insert(newBranches, position-1, branchName)
+
+	sm.warnChildCommitsInParent(stack.Branches, newBranches)
+
+	stack.Branches = newBranches
+	sm.stacks.SaveStacks()
+	sm.printer.Println("Branch", color.Yellow(branchName), "moved to position", position, "in", color.Green(stack.Name))
+
+	if sm.prompter.Confirm("Run sync now?") {
+		return sm.Sync(false, false)
+	}
+	return nil
+}
+
+// warnChildCommitsInParent checks every new parent/child pair of the stack.
+// When a branch is now below a branch it was previously merged from,
+// its history already contains commits of its new child and syncing will not remove them.
+func (sm StacksManager) warnChildCommitsInParent(oldBranches []string, newBranches []string) {
+	for i := 1; i < len(newBranches); i++ {
+		parent := newBranches[i-1]
+		child := newBranches[i]
+
+		oldIndex := slices.Index(oldBranches, child)
+		var oldParent string
+		if oldIndex > 0 {
+			oldParent = oldBranches[oldIndex-1]
+		} else {
+			defaultBranch, err := sm.defaultBranchWithRemote()
+			if err != nil {
+				continue
+			}
+			oldParent = defaultBranch
+		}
+
+		if oldParent == parent {
+			continue
+		}
+
+		childCommits, err := sm.commitsCount(child, oldParent)
+		if err != nil {
+			continue
+		}
+		childOnlyCommits, err := sm.commitsCount(child, oldParent, parent)
+		if err != nil {
+			continue
+		}
+
+		if shared := childCommits - childOnlyCommits; shared > 0 {
+			sm.printer.Println(
+				color.Red("Warning:"),
+				"branch", color.Yellow(parent),
+				"already contains", shared, "commit(s) from its new child", color.Yellow(child),
+			)
+		}
+	}
+}
+
 func (sm StacksManager) Delete(stackName string) error {
 	sm.stacks.LoadStacks()
 	var filteredStacks []Stack
@@ -280,6 +459,95 @@ func (sm StacksManager) Delete(stackName string) error {
 	return nil
 }
 
+func (sm StacksManager) RenameStack(oldName string, newName string) error {
+	sm.stacks.LoadStacks()
+	stack, err := sm.stacks.GetStackByName(oldName)
+	if err != nil {
+		return err
+	}
+
+	if _, err = sm.stacks.GetStackByName(newName); err == nil {
+		return errors.New("stack " + color.Green(newName) + " already exists")
+	}
+
+	stack.Name = newName
+	if sm.stacks.CurrentStack == oldName {
+		sm.stacks.CurrentStack = newName
+	}
+	if sm.stacks.InterruptedSync != nil && sm.stacks.InterruptedSync.Stack == oldName {
+		sm.stacks.InterruptedSync.Stack = newName
+	}
+	sm.stacks.SaveStacks()
+	sm.printer.Println("Stack", color.Green(oldName), "renamed to", color.Green(newName))
+	return nil
+}
+
+// RenameBranch renames the git branch and the branch in every stack that contains it.
+// With renameRemote, the remote branch is renamed too. When the branch has a pull request,
+// the rename is done with GH-CLI so GitHub keeps the pull request on the renamed branch.
+func (sm StacksManager) RenameBranch(oldName string, newName string, renameRemote bool) error {
+	sm.stacks.LoadStacks()
+	if !sm.branchExists(oldName) {
+		return errors.New("branch " + color.Yellow(oldName) + " does not exist")
+	}
+	if sm.branchExists(newName) {
+		return errors.New("branch " + color.Yellow(newName) + " already exists")
+	}
+
+	err := sm.renameBranch(oldName, newName)
+	if err != nil {
+		return err
+	}
+	sm.stacks.RenameBranch(oldName, newName)
+	sm.stacks.SaveStacks()
+	sm.printer.Println("Branch", color.Yellow(oldName), "renamed to", color.Yellow(newName))
+
+	if !renameRemote {
+		return nil
+	}
+
+	if !sm.remoteBranchExists(oldName) {
+		sm.printer.Println("No remote branch for", color.Yellow(oldName))
+		return nil
+	}
+
+	if sm.ghCliConfigure() == nil {
+		prNumber, err := sm.ghPrNumber(oldName)
+		if err == nil && prNumber != "" {
+			return sm.renameRemoteBranchWithPr(oldName, newName, prNumber)
+		}
+	}
+
+	sm.printer.Println("Publishing", color.Yellow(newName)+"...")
+	err = sm.publishBranch(newName)
+	if err != nil {
+		return err
+	}
+	err = sm.deleteRemoteBranch(oldName)
+	if err != nil {
+		return err
+	}
+	sm.printer.Println("Remote branch", color.Yellow(oldName), "renamed to", color.Yellow(newName))
+	return nil
+}
+
+func (sm StacksManager) renameRemoteBranchWithPr(oldName string, newName string, prNumber string) error {
+	err := sm.ghRenameRemoteBranch(oldName, newName)
+	if err != nil {
+		return err
+	}
+	err = sm.fetchPrune()
+	if err != nil {
+		return err
+	}
+	err = sm.setUpstream(newName)
+	if err != nil {
+		return err
+	}
+	sm.printer.Println("Remote branch", color.Yellow(oldName), "renamed to", color.Yellow(newName), "with pull request", "#"+prNumber)
+	return nil
+}
+
 func (sm StacksManager) CheckoutByName(branchName string) error {
 	return sm.checkout(branchName)
 }
@@ -309,6 +577,10 @@ func (sm StacksManager) Sync(push bool, mergeDefaultBranch bool) error {
 	}
 
 	sm.printer.Println("Syncing", color.Green(data.CurrentStack))
+	err = sm.runHook(HookPreSync, data.CurrentStack, nil, -1)
+	if err != nil {
+		return err
+	}
 
 	sm.printer.Println("Fetching...")
 	err = sm.fetch()
@@ -319,86 +591,142 @@ func (sm StacksManager) Sync(push bool, mergeDefaultBranch bool) error {
 	branches, _ := data.GetBranchesByName(data.CurrentStack)
 
 	for i, branch := range branches {
-		sm.printer.Println("Branch:", color.Yellow(branch))
-		sm.printer.Println("\tCheckout...")
-		err = sm.checkout(branch)
-		if err != nil {
-			return err
+		if sm.interrupted() {
+			return sm.interruptSync(branch, checkoutBranchEnd)
 		}
 
-		sm.printer.Println("\tPull...")
-		err = sm.pullBranch()
+		err = sm.syncBranch(branches, i, push, mergeDefaultBranch)
 		if err != nil {
-			return err
-		}
-
-		if i == 0 {
-			err = sm.syncFirstBranch(branch, push, mergeDefaultBranch)
-			if err != nil {
-				return err
+			if sm.interrupted() {
+				return sm.interruptSync(branch, checkoutBranchEnd)
 			}
-			continue
-		}
-
-		parentBranch := branches[i-1]
-		sm.printer.Println("\tMerging", color.Yellow(parentBranch))
-		err = sm.merge(branch, parentBranch)
-		if err != nil {
 			return err
 		}
-		if push {
-			sm.printer.Println("\tPushing...")
-			err = sm.pushBranch()
-			if err != nil {
-				return err
-			}
-		}
 	}
 
-	return sm.checkout(checkoutBranchEnd)
+	if data.InterruptedSync != nil && data.InterruptedSync.Stack == data.CurrentStack {
+		sm.stacks.InterruptedSync = nil
+		sm.stacks.SaveStacks()
+	}
+
+	err = sm.checkout(checkoutBranchEnd)
+	if err != nil {
+		return err
+	}
+	sm.runPostHook(HookPostSync, data.CurrentStack, nil, -1)
+	return nil
 }
 
-func (sm StacksManager) Tree() error {
-	sm.stacks.LoadStacks()
-	branches, _ := sm.stacks.GetCurrentBranches()
+func (sm StacksManager) syncBranch(branches []string, i int, push bool, mergeDefaultBranch bool) error {
+	branch := branches[i]
+	sm.printer.Println("Branch:", color.Yellow(branch))
+	sm.printer.Println("\tCheckout...")
+	err := sm.checkout(branch)
+	if err != nil {
+		return err
+	}
 
-	sm.printer.Println("Current stack:", color.Green(sm.stacks.CurrentStack), "\n")
-	treeOutput := ""
-	defaultBranch, err := sm.defaultBranchWithRemote()
+	sm.printer.Println("\tPull...")
+	err = sm.pullBranch()
 	if err != nil {
 		return err
 	}
-	branches = append([]string{defaultBranch}, branches...)
-	lastIndex := len(branches) - 1
 
-	for i, branch := range branches {
-		if i == lastIndex {
-			continue
+	if i == 0 {
+		return sm.syncFirstBranch(branch, push, mergeDefaultBranch)
+	}
+
+	parentBranch := branches[i-1]
+	sm.printer.Println("\tMerging", color.Yellow(parentBranch))
+	err = sm.merge(branch, parentBranch)
+	if err != nil {
+		return err
+	}
+	sm.runPostMergeHook(sm.stacks.CurrentStack, branches, i, parentBranch)
+	if push {
+		sm.printer.Println("\tPushing...")
+		return sm.pushBranch()
+	}
+	return nil
+}
+
+// interrupted is true once the context of the manager is done, e.g. after Ctrl-C.
+func (sm StacksManager) interrupted() bool {
+	return sm.ctx != nil && sm.ctx.Err() != nil
+}
+
+// interruptSync records the branch where the sync stopped so status can tell it
+// until a sync of the stack completes. It aborts the merge stopped halfway
+// and checks out checkoutBranchEnd again, like at the end of a sync.
+func (sm StacksManager) interruptSync(branch string, checkoutBranchEnd string) error {
+	sm.stacks.InterruptedSync = &InterruptedSync{
+		Stack:  sm.stacks.CurrentStack,
+		Branch: branch,
+	}
+	sm.stacks.SaveStacks()
+
+	message := "sync interrupted at branch " + color.Yellow(branch) + ", run `" + color.Magenta("gostacking sync") + "` again to finish it"
+	if _, err := sm.cleanUpExec("rev-parse", "-q", "--verify", "MERGE_HEAD"); err == nil {
+		if output, err := sm.cleanUpExec("merge", "--abort"); err != nil {
+			return errors.New(message + "\nfailed to abort the merge\n" + output)
 		}
+	}
+	if output, err := sm.cleanUpExec("checkout", checkoutBranchEnd); err != nil {
+		return errors.New(message + "\nfailed to checkout " + color.Yellow(checkoutBranchEnd) + "\n" + output)
+	}
+	return errors.New(message)
+}
 
-		branchColor := colorFunc(i)
+// cleanUpExec runs a git command even when the context of the manager is done,
+// to clean up after an interrupt.
+func (sm StacksManager) cleanUpExec(command ...string) (string, error) {
+	executor, ok := sm.gitExecutor.(cliexec.InterfaceContextExecutor)
+	if !ok || sm.ctx == nil {
+		return sm.gitExecutor.Exec(command...)
+	}
+	return executor.ExecContext(context.WithoutCancel(sm.ctx), command...)
+}
 
-		if i == 0 {
-			treeOutput += branchColor("* "+branches[i+1]) + "\n"
-		} else {
-			treeOutput += pipesColors(i, false) + branchColor("* "+branches[i+1]) + "\n"
+// Tree shows the branches of the current stack, or of every stack with options.All,
+// from the default branch with their commits, as text or a graph (see treeFormatters).
+func (sm StacksManager) Tree(options TreeOptions) error {
+	sm.stacks.LoadStacks()
+	format := options.Format
+	if format == "" {
+		format = TreeFormatText
+	}
+	formatter, ok := treeFormatters[format]
+	if !ok {
+		return errors.New("invalid format " + format + ", must be text, dot or mermaid")
+	}
+	if sm.output == OutputJSON && format != TreeFormatText {
+		return errors.New("the " + format + " format can't be used with the JSON output")
+	}
+
+	stackNames := []string{sm.stacks.CurrentStack}
+	if options.All {
+		stackNames = nil
+		for _, stack := range sm.stacks.Stacks {
+			stackNames = append(stackNames, stack.Name)
 		}
-		commits, err := sm.commitsBetweenBranches(branch, branches[i+1])
+	}
+
+	reports := []TreeReport{}
+	for _, stackName := range stackNames {
+		report, err := sm.treeReport(stackName, options)
 		if err != nil {
 			return err
 		}
+		reports = append(reports, report)
+	}
 
-		for _, commit := range commits {
-			commitHash := color.DarkYellow(strings.Split(commit, " ")[0])
-			restOfCommit := strings.Join(strings.Split(commit, " ")[1:], " ")
-			treeOutput += pipesColors(i+1, false) + commitHash + " " + restOfCommit + "\n"
-		}
-
-		if i != lastIndex-1 {
-			treeOutput += pipesColors(i+1, true)
+	if sm.output == OutputJSON {
+		if options.All {
+			return sm.printJSON(reports)
 		}
+		return sm.printJSON(reports[0])
 	}
-	sm.printer.Println(treeOutput)
+	sm.printer.Println(formatter(reports, sm.stacks.CurrentStack))
 	return nil
 }
 
@@ -421,6 +749,11 @@ func (sm StacksManager) Publish() error {
 		)
 	}
 
+	err = sm.runHook(HookPrePublish, data.CurrentStack, branches, slices.Index(branches, currentBranch))
+	if err != nil {
+		return err
+	}
+
 	sm.printer.Println("Publishing", color.Yellow(currentBranch)+"...")
 	err = sm.publishBranch(currentBranch)
 	if err != nil {
@@ -504,6 +837,7 @@ func (sm StacksManager) syncFirstBranch(firstBranch string, push bool, mergeDefa
 		if err != nil {
 			return err
 		}
+		sm.runPostMergeHook(sm.stacks.CurrentStack, []string{firstBranch}, 0, defaultBranch)
 	}
 
 	if push {
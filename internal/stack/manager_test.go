@@ -1,10 +1,13 @@
 package stack
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Bhacaz/gostacking/internal/cliexec"
 	"github.com/Bhacaz/gostacking/internal/color"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -22,6 +25,18 @@ func (p PrinterStub) Println(a ...interface{}) {
 	*p.MessageReceived = append(*p.MessageReceived, "\n")
 }
 
+type PrompterStub struct {
+	Answer            bool
+	QuestionsReceived *[]string
+}
+
+func (p PrompterStub) Confirm(question string) bool {
+	if p.QuestionsReceived != nil {
+		*p.QuestionsReceived = append(*p.QuestionsReceived, question)
+	}
+	return p.Answer
+}
+
 type cliExecutorStub struct {
 	stubExec func(...string) (string, error)
 }
@@ -30,6 +45,16 @@ func (g cliExecutorStub) Exec(command ...string) (string, error) {
 	return g.stubExec(command...)
 }
 
+// contextExecutorStub is a cliexec.InterfaceContextExecutor.
+type contextExecutorStub struct {
+	cliExecutorStub
+	stubExecContext func(ctx context.Context, command ...string) (string, error)
+}
+
+func (g contextExecutorStub) ExecContext(ctx context.Context, command ...string) (string, error) {
+	return g.stubExecContext(ctx, command...)
+}
+
 func (sm StacksManager) printerMessage() string {
 	return strings.Join(*sm.printer.(PrinterStub).MessageReceived, "")
 }
@@ -41,6 +66,7 @@ func StacksManagerForTest(gitExecutor cliexec.InterfaceCliExecutor, messageRecei
 		printer: PrinterStub{
 			MessageReceived: messageReceived,
 		},
+		prompter: PrompterStub{},
 	}
 }
 
@@ -98,10 +124,9 @@ func TestStacksManager_CurrentStackStatus(t *testing.T) {
 	t.Run("current stack status", func(t *testing.T) {
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
-				joinedCommand := strings.Join(command, " ")
-				// Ensure not being behind remote AND no diff with parent branch
-				if strings.HasPrefix(joinedCommand, "diff") {
-					return "", nil
+				// Ensure not being behind remote AND no commits from parent branch
+				if command[0] == "rev-list" {
+					return "0\t0", nil
 				}
 				return "something", nil
 			},
@@ -115,7 +140,8 @@ func TestStacksManager_CurrentStackStatus(t *testing.T) {
 			`Current stack: %s
 Branches:
 1. %s
-2. %s`,
+2. %s
+`,
 			color.Green("stack1"),
 			color.Yellow("branch1"),
 			color.Yellow("branch2"),
@@ -130,14 +156,38 @@ Branches:
 		}
 	})
 
+	t.Run("when the last sync was interrupted", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "rev-list" {
+					return "0\t0", nil
+				}
+				return "something", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.InterruptedSync = &InterruptedSync{Stack: "stack1", Branch: "branch2"}
+
+		result := stacksManager.CurrentStackStatus(false)
+
+		want := color.Red("Last sync was interrupted at branch branch2")
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+
+		if result != nil {
+			t.Errorf("show have no error, got %s", result)
+		}
+	})
+
 	t.Run("current stack status with log", func(t *testing.T) {
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
-				joinedCommand := strings.Join(command, " ")
-				// Ensure not being behind remote AND no diff with parent branch
-				if strings.HasPrefix(joinedCommand, "diff") {
-					return "", nil
-				} else if strings.HasPrefix(joinedCommand, "log") {
+				// Ensure not being behind remote AND no commits from parent branch
+				if command[0] == "rev-list" {
+					return "0\t0", nil
+				} else if command[0] == "log" {
 					return "log", nil
 				}
 				return "something", nil
@@ -189,13 +239,14 @@ Branches:
 		}
 	})
 
-	t.Run("when branch1 is behind remote", func(t *testing.T) {
+	t.Run("when branch1 is behind and ahead of remote", func(t *testing.T) {
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
 				joinedCommand := strings.Join(command, " ")
-				// branch1 is behind remove
-				if "diff --name-only branch1...origin/branch1" == joinedCommand {
-					return "file1.txt", nil
+				if "rev-list --left-right --count branch1...origin/branch1" == joinedCommand {
+					return "1\t3", nil
+				} else if command[0] == "rev-list" {
+					return "0\t0", nil
 				}
 				return "", nil
 			},
@@ -208,11 +259,13 @@ Branches:
 		want := fmt.Sprintf(
 			`Current stack: %s
 Branches:
-1. %s %s
-2. %s`,
+1. %s %s %s
+2. %s
+`,
 			color.Green("stack1"),
 			color.Yellow("branch1"),
-			color.Teal("↓"),
+			color.Teal("↓3"),
+			color.Teal("↑1"),
 			color.Yellow("branch2"),
 		)
 
@@ -229,10 +282,11 @@ Branches:
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
 				joinedCommand := strings.Join(command, " ")
-				// Ensure not being behind remote AND no diff with parent branch
-				if "diff --name-only branch1...origin/branch1" == joinedCommand {
-					return "file1.txt", nil
-				} else if strings.HasPrefix(joinedCommand, "log") {
+				if "rev-list --left-right --count branch1...origin/branch1" == joinedCommand {
+					return "0\t2", nil
+				} else if command[0] == "rev-list" {
+					return "0\t0", nil
+				} else if command[0] == "log" {
 					return "log", nil
 				}
 				return "", nil
@@ -252,7 +306,7 @@ Branches:
 	log`,
 			color.Green("stack1"),
 			color.Yellow("branch1"),
-			color.Teal("↓"),
+			color.Teal("↓2"),
 			color.Yellow("branch2"),
 		)
 
@@ -269,9 +323,10 @@ Branches:
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
 				joinedCommand := strings.Join(command, " ")
-				// branch1 is behind remove
-				if "diff --name-only branch1...origin/branch1" == joinedCommand {
+				if "rev-list --left-right --count branch1...origin/branch1" == joinedCommand {
 					return "", fmt.Errorf("git command error")
+				} else if command[0] == "rev-list" {
+					return "0\t0", nil
 				}
 				return "", nil
 			},
@@ -285,9 +340,50 @@ Branches:
 			`Current stack: %s
 Branches:
 1. %s
-2. %s`,
+2. %s
+`,
+			color.Green("stack1"),
+			color.Yellow("branch1"),
+			color.Yellow("branch2"),
+		)
+
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+
+		if result != nil {
+			t.Errorf("show have no error, got %s", result)
+		}
+	})
+
+	t.Run("when branch1 is behind the default branch", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				if "symbolic-ref refs/remotes/origin/HEAD --short" == joinedCommand {
+					return "origin/main", nil
+				} else if "rev-list --left-right --count branch1...origin/main" == joinedCommand {
+					return "4\t12", nil
+				} else if command[0] == "rev-list" {
+					return "0\t0", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		result := stacksManager.CurrentStackStatus(false)
+
+		want := fmt.Sprintf(
+			`Current stack: %s
+Branches:
+1. %s %s
+2. %s
+`,
 			color.Green("stack1"),
 			color.Yellow("branch1"),
+			color.Magenta("⇣12"),
 			color.Yellow("branch2"),
 		)
 
@@ -300,14 +396,15 @@ Branches:
 		}
 	})
 
-	t.Run("when branch2 has diff with parent branch", func(t *testing.T) {
+	t.Run("when branch2 is behind parent branch", func(t *testing.T) {
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
 				joinedCommand := strings.Join(command, " ")
-				fmt.Println(joinedCommand)
-				// branch2 has diff with parent branch
-				if "diff --name-only branch2...branch1" == joinedCommand {
-					return "file2.txt", nil
+				// branch1 has a merge commit not in branch2, with or without file changes
+				if "rev-list --left-right --count branch2...branch1" == joinedCommand {
+					return "5\t1", nil
+				} else if command[0] == "rev-list" {
+					return "0\t0", nil
 				}
 				return "", nil
 			},
@@ -321,11 +418,12 @@ Branches:
 			`Current stack: %s
 Branches:
 1. %s
-2. %s %s`,
+2. %s %s
+`,
 			color.Green("stack1"),
 			color.Yellow("branch1"),
 			color.Yellow("branch2"),
-			color.Red("*"),
+			color.Red("⇣1"),
 		)
 
 		if !strings.Contains(stacksManager.printerMessage(), want) {
@@ -338,13 +436,14 @@ Branches:
 
 	})
 
-	t.Run("when branch2 has diff return an error", func(t *testing.T) {
+	t.Run("when branch2 count with parent return an error", func(t *testing.T) {
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
 				joinedCommand := strings.Join(command, " ")
-				fmt.Println(joinedCommand)
-				if "diff --name-only branch2...branch1" == joinedCommand {
-					return "", fmt.Errorf("git diff command error")
+				if "rev-list --left-right --count branch2...branch1" == joinedCommand {
+					return "", fmt.Errorf("git rev-list command error")
+				} else if command[0] == "rev-list" {
+					return "0\t0", nil
 				}
 				return "", nil
 			},
@@ -358,7 +457,8 @@ Branches:
 			`Current stack: %s
 Branches:
 1. %s
-2. %s`,
+2. %s
+`,
 			color.Green("stack1"),
 			color.Yellow("branch1"),
 			color.Yellow("branch2"),
@@ -845,6 +945,170 @@ func TestStacksManager_StacksManager_RemoveByNumber(t *testing.T) {
 	})
 }
 
+func TestStacksManager_MoveByName(t *testing.T) {
+	t.Run("move branch by name", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				return "0", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.MoveByName("branch2", 1)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := "Branch " + color.Yellow("branch2") + " moved to position 1 in " + color.Green("stack1")
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"branch2", "branch1"}) {
+			t.Errorf("got %s, want %s", got, []string{"branch2", "branch1"})
+		}
+	})
+
+	t.Run("when branch does not exist", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+
+		err := stacksManager.MoveByName("non_existing_branch", 1)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := "Branch " + color.Yellow("non_existing_branch") + " does not exist"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("when the parent already contains commits from its new child", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				switch strings.Join(command, " ") {
+				case "symbolic-ref refs/remotes/origin/HEAD --short":
+					return "origin/main", nil
+				// branch1 own commits
+				case "rev-list --count --no-merges branch1 ^origin/main":
+					return "3", nil
+				// branch1 commits not in branch2
+				case "rev-list --count --no-merges branch1 ^origin/main ^branch2":
+					return "0", nil
+				}
+				return "0", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.MoveByName("branch2", 1)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := color.Red("Warning:") + " branch " + color.Yellow("branch2") +
+			" already contains 3 commit(s) from its new child " + color.Yellow("branch1")
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("when sync is accepted", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommandsReceived = append(gitCommandsReceived, strings.Join(command, " "))
+				if command[0] == "rev-list" {
+					return "0", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		var questionsReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.prompter = PrompterStub{Answer: true, QuestionsReceived: &questionsReceived}
+
+		err := stacksManager.MoveByName("branch2", 1)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		if !reflect.DeepEqual(questionsReceived, []string{"Run sync now?"}) {
+			t.Errorf("got %s, want %s", questionsReceived, []string{"Run sync now?"})
+		}
+
+		want := "Syncing " + color.Green("stack1")
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+		if !slices.Contains(gitCommandsReceived, "merge branch2 -m Merge branch branch2 into branch1 (gostacking)") {
+			t.Errorf("branch2 should have been merged into branch1, got %s", gitCommandsReceived)
+		}
+	})
+}
+
+func TestStacksManager_MoveByNumber(t *testing.T) {
+	t.Run("move branch by number", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				return "0", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.MoveByNumber(1, 2)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"branch2", "branch1"}) {
+			t.Errorf("got %s, want %s", got, []string{"branch2", "branch1"})
+		}
+	})
+
+	t.Run("when number is invalid", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+
+		err := stacksManager.MoveByNumber(3, 1)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := "Invalid branch number"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	})
+
+	t.Run("when position is invalid", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+
+		err := stacksManager.MoveByNumber(1, 3)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := "Invalid position: 3"
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+
+		got := stacksManager.stacks.Stacks[0].Branches
+		if !reflect.DeepEqual(got, []string{"branch1", "branch2"}) {
+			t.Errorf("got %s, want %s", got, []string{"branch1", "branch2"})
+		}
+	})
+}
+
 func TestStacksManager_StacksManager_Delete(t *testing.T) {
 	t.Run("delete stack", func(t *testing.T) {
 		var messageReceived []string
@@ -899,6 +1163,188 @@ func TestStacksManager_StacksManager_Delete(t *testing.T) {
 	})
 }
 
+func TestStacksManager_RenameStack(t *testing.T) {
+	t.Run("rename the current stack", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+
+		err := stacksManager.RenameStack("stack1", "my-stack")
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := "Stack " + color.Green("stack1") + " renamed to " + color.Green("my-stack")
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+
+		if stacksManager.stacks.Stacks[0].Name != "my-stack" {
+			t.Errorf("got %s, want %s", stacksManager.stacks.Stacks[0].Name, "my-stack")
+		}
+		if stacksManager.stacks.CurrentStack != "my-stack" {
+			t.Errorf("got %s, want %s", stacksManager.stacks.CurrentStack, "my-stack")
+		}
+	})
+
+	t.Run("when the stack does not exist", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+
+		err := stacksManager.RenameStack("non_existing_stack", "my-stack")
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("when the new name is already used", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(nil, &messageReceived)
+
+		err := stacksManager.RenameStack("stack1", "stack2")
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+		if stacksManager.stacks.Stacks[0].Name != "stack1" {
+			t.Errorf("got %s, want %s", stacksManager.stacks.Stacks[0].Name, "stack1")
+		}
+	})
+}
+
+func TestStacksManager_RenameBranch(t *testing.T) {
+	t.Run("rename the local branch in every stack", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				gitCommandsReceived = append(gitCommandsReceived, joinedCommand)
+				if joinedCommand == "rev-parse --verify new_branch" {
+					return "", fmt.Errorf("branch does not exist")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.Stacks[1].Branches = []string{"branch2", "branch4"}
+
+		err := stacksManager.RenameBranch("branch2", "new_branch", false)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		if gitCommandsReceived[len(gitCommandsReceived)-1] != "branch -m branch2 new_branch" {
+			t.Errorf("got %s, want %s", gitCommandsReceived, "branch -m branch2 new_branch")
+		}
+
+		if !reflect.DeepEqual(stacksManager.stacks.Stacks[0].Branches, []string{"branch1", "new_branch"}) {
+			t.Errorf("got %s, want %s", stacksManager.stacks.Stacks[0].Branches, []string{"branch1", "new_branch"})
+		}
+		if !reflect.DeepEqual(stacksManager.stacks.Stacks[1].Branches, []string{"new_branch", "branch4"}) {
+			t.Errorf("got %s, want %s", stacksManager.stacks.Stacks[1].Branches, []string{"new_branch", "branch4"})
+		}
+	})
+
+	t.Run("when the new branch already exists", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "branch" {
+					t.Errorf("unwanted git command: %s", command)
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.RenameBranch("branch2", "branch1", false)
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("rename the remote branch without pull request", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				gitCommandsReceived = append(gitCommandsReceived, joinedCommand)
+				if joinedCommand == "rev-parse --verify new_branch" {
+					return "", fmt.Errorf("branch does not exist")
+				}
+				return "", nil
+			},
+		}
+		ghExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "pr" {
+					return "no pull requests found for branch", fmt.Errorf("no pull requests found for branch")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.ghExecutor = ghExecutor
+
+		err := stacksManager.RenameBranch("branch2", "new_branch", true)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		want := []string{"push -u origin new_branch", "push origin --delete branch2"}
+		if !reflect.DeepEqual(gitCommandsReceived[len(gitCommandsReceived)-2:], want) {
+			t.Errorf("got %s, want %s", gitCommandsReceived, want)
+		}
+	})
+
+	t.Run("rename the remote branch with a pull request", func(t *testing.T) {
+		var gitCommandsReceived []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				joinedCommand := strings.Join(command, " ")
+				gitCommandsReceived = append(gitCommandsReceived, joinedCommand)
+				if joinedCommand == "rev-parse --verify new_branch" {
+					return "", fmt.Errorf("branch does not exist")
+				}
+				return "", nil
+			},
+		}
+		var ghCommandsReceived []string
+		ghExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				ghCommandsReceived = append(ghCommandsReceived, strings.Join(command, " "))
+				if command[0] == "pr" {
+					return "123", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.ghExecutor = ghExecutor
+
+		err := stacksManager.RenameBranch("branch2", "new_branch", true)
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+
+		wantGh := "api --method POST repos/{owner}/{repo}/branches/branch2/rename -f new_name=new_branch"
+		if ghCommandsReceived[len(ghCommandsReceived)-1] != wantGh {
+			t.Errorf("got %s, want %s", ghCommandsReceived, wantGh)
+		}
+
+		want := []string{"fetch --prune origin", "branch --set-upstream-to origin/new_branch new_branch"}
+		if !reflect.DeepEqual(gitCommandsReceived[len(gitCommandsReceived)-2:], want) {
+			t.Errorf("got %s, want %s", gitCommandsReceived, want)
+		}
+
+		wantMessage := "with pull request #123"
+		if !strings.Contains(stacksManager.printerMessage(), wantMessage) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), wantMessage)
+		}
+	})
+}
+
 func TestStacksManager_CheckoutByName(t *testing.T) {
 	t.Run("checkout branch by name", func(t *testing.T) {
 		var gitCommandsReceived []string
@@ -1398,19 +1844,135 @@ Branch: %s
 			t.Errorf("got \"%s\", want \"%s\"", err.Error(), want)
 		}
 	})
+	t.Run("when interrupted between branches", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var checkedOut []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				if command[0] == "checkout" {
+					checkedOut = append(checkedOut, command[1])
+				}
+				if command[0] == "pull" {
+					// Ctrl-C while syncing branch1
+					cancel()
+				}
+				if strings.Join(command, " ") == "rev-parse -q --verify MERGE_HEAD" {
+					return "", errors.New("exit status 1")
+				}
+				if command[0] == "rev-parse" {
+					return "branch2", nil
+				}
+				return "", nil
+			},
+		}
+
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.ctx = ctx
+
+		err := stacksManager.Sync(false, false)
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+		want := "sync interrupted at branch " + color.Yellow("branch2")
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", err.Error(), want)
+		}
+		// Back on the branch where the sync started
+		if !reflect.DeepEqual(checkedOut, []string{"branch1", "branch2"}) {
+			t.Errorf("got %s, want %s", checkedOut, []string{"branch1", "branch2"})
+		}
+		wantInterrupted := InterruptedSync{Stack: "stack1", Branch: "branch2"}
+		if stacksManager.stacks.InterruptedSync == nil || *stacksManager.stacks.InterruptedSync != wantInterrupted {
+			t.Errorf("got %v, want %v", stacksManager.stacks.InterruptedSync, wantInterrupted)
+		}
+	})
+
+	t.Run("when a command is interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var cleanUpCommands []string
+		gitExecutor := contextExecutorStub{
+			cliExecutorStub: cliExecutorStub{
+				stubExec: func(command ...string) (string, error) {
+					if strings.Join(command, " ") == "rev-parse --abbrev-ref HEAD" {
+						return "branch1", nil
+					}
+					if command[0] == "merge" {
+						cancel()
+						return "git merge interrupted", cliexec.ErrInterrupted
+					}
+					return "", nil
+				},
+			},
+			stubExecContext: func(ctx context.Context, command ...string) (string, error) {
+				if ctx.Err() != nil {
+					return "", cliexec.ErrInterrupted
+				}
+				cleanUpCommands = append(cleanUpCommands, strings.Join(command, " "))
+				return "", nil
+			},
+		}
+
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.ctx = ctx
+
+		err := stacksManager.Sync(false, false)
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+		want := "sync interrupted at branch " + color.Yellow("branch2")
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", err.Error(), want)
+		}
+		// The merge killed halfway is aborted, even if the context is done
+		wantCleanUp := []string{"rev-parse -q --verify MERGE_HEAD", "merge --abort", "checkout branch1"}
+		if !reflect.DeepEqual(cleanUpCommands, wantCleanUp) {
+			t.Errorf("got %s, want %s", cleanUpCommands, wantCleanUp)
+		}
+	})
+
+	t.Run("when the previous sync was interrupted", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				return "", nil
+			},
+		}
+
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+		stacksManager.stacks.InterruptedSync = &InterruptedSync{Stack: "stack1", Branch: "branch2"}
+
+		err := stacksManager.Sync(false, false)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if stacksManager.stacks.InterruptedSync != nil {
+			t.Errorf("got %v, want nil", stacksManager.stacks.InterruptedSync)
+		}
+	})
 }
 
 func TestStacksManager_Tree(t *testing.T) {
 	t.Run("tree", func(t *testing.T) {
 		gitExecutor := cliExecutorStub{
 			stubExec: func(command ...string) (string, error) {
-				return "abcdef Some commit message - 3 minutes ago", nil
+				if command[0] == "log" {
+					return "abcdef\x1fSome commit message\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f3 minutes ago", nil
+				}
+				if command[0] == "diff" {
+					return " 1 file changed, 2 insertions(+)", nil
+				}
+				return "origin/main", nil
 			},
 		}
 		var messageReceived []string
 		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
 
-		err := stacksManager.Tree()
+		err := stacksManager.Tree(TreeOptions{})
 
 		if err != nil {
 			t.Errorf("show have no error, got %s", err)
@@ -1419,17 +1981,21 @@ func TestStacksManager_Tree(t *testing.T) {
 		want := fmt.Sprintf(
 			`Current stack: %s 
 
+%s
 %s
 %s Some commit message - 3 minutes ago
 %s%s
+%s
 %s Some commit message - 3 minutes ago
 
 `,
 			color.Green("stack1"),
 			color.Red("* branch1"),
+			color.Red("| ")+"1 commit, 1 file changed, "+color.Green("+2")+" "+color.Red("-0"),
 			color.Red("| ")+color.DarkYellow("abcdef"),
 			color.Red("|\\\n"),
 			color.Red("| ")+color.Purple("* branch2"),
+			color.Red("| ")+color.Purple("| ")+"1 commit, 1 file changed, "+color.Green("+2")+" "+color.Red("-0"),
 			color.Red("| ")+color.Purple("| ")+color.DarkYellow("abcdef"),
 		)
 		if !strings.Contains(stacksManager.printerMessage(), want) {
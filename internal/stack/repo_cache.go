@@ -0,0 +1,35 @@
+package stack
+
+import "sync"
+
+// repoCache keeps the repository wide values for the lifetime of the manager,
+// they are read by concurrent workers (see statusReport).
+type repoCache struct {
+	mutex         sync.Mutex
+	defaultBranch string
+}
+
+func newRepoCache() *repoCache {
+	return &repoCache{}
+}
+
+// defaultBranchWithRemote returns the cached default branch or load it.
+// Errors are not cached, so a fixed origin/HEAD is read again.
+func (c *repoCache) defaultBranchWithRemote(load func() (string, error)) (string, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.defaultBranch != "" {
+		return c.defaultBranch, nil
+	}
+
+	defaultBranch, err := load()
+	if err != nil {
+		return "", err
+	}
+	c.defaultBranch = defaultBranch
+	return defaultBranch, nil
+}
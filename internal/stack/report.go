@@ -0,0 +1,280 @@
+package stack
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// The reports are the JSON output of the status, list and tree commands (--output json).
+// They are used by scripts and editor plugins: fields can be added, but never renamed or removed.
+
+// StatusReport is the output of the status command.
+type StatusReport struct {
+	// Stack is the name of the current stack
+	Stack    string         `json:"stack"`
+	Branches []BranchReport `json:"branches"`
+}
+
+type BranchReport struct {
+	// Position of the branch in the stack, starting at 1
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	// Parent is the previous branch in the stack, or the default branch with its remote (e.g. origin/main) for the first one
+	Parent string       `json:"parent"`
+	Status branchStatus `json:"status"`
+	// Remote is the number of commits ahead and behind the remote branch, null if the branch has no remote
+	Remote     *AheadBehindReport `json:"remote"`
+	LastCommit *CommitReport      `json:"lastCommit"`
+}
+
+type AheadBehindReport struct {
+	Ahead  int `json:"ahead"`
+	Behind int `json:"behind"`
+}
+
+type CommitReport struct {
+	// Hash is the abbreviated commit hash
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	Author  string `json:"author"`
+	// Date is the committer date in strict ISO 8601 format
+	Date string `json:"date"`
+	// RelativeDate is the committer date relative to now (e.g. 3 minutes ago)
+	RelativeDate string `json:"relativeDate"`
+	// Stat of the commit, only with tree --stat
+	Stat *DiffStat `json:"stat,omitempty"`
+}
+
+type SyncReport struct {
+	// LastSync is the most recent merge of sync in the branch, null if it was never synced
+	LastSync *CommitReport `json:"lastSync"`
+	// BehindParentCount is the number of commits of the parent not merged yet, the parent moved since the last sync
+	BehindParentCount int `json:"behindParentCount"`
+}
+
+type DiffStat struct {
+	FilesChanged int `json:"filesChanged"`
+	Insertions   int `json:"insertions"`
+	Deletions    int `json:"deletions"`
+}
+
+// ListReport is the output of the list command.
+type ListReport struct {
+	CurrentStack string        `json:"currentStack"`
+	Stacks       []StackReport `json:"stacks"`
+}
+
+type StackReport struct {
+	// Position of the stack in the list, starting at 1
+	Position int      `json:"position"`
+	Name     string   `json:"name"`
+	Branches []string `json:"branches"`
+}
+
+// TreeReport is the output of the tree command.
+type TreeReport struct {
+	Stack string `json:"stack"`
+	// DefaultBranch is the default branch with its remote (e.g. origin/main), root of the tree
+	DefaultBranch string             `json:"defaultBranch"`
+	Branches      []TreeBranchReport `json:"branches"`
+	// CommitsFiltered is true when the commits are limited to an author or a date,
+	// the stat of the branches is still the diff of all their commits
+	CommitsFiltered bool `json:"commitsFiltered,omitempty"`
+}
+
+type TreeBranchReport struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
+	// Commits are the commits of the branch not in its parent, without merges, oldest first
+	Commits []CommitReport `json:"commits"`
+	// Stat of the changes of the branch since its parent
+	Stat DiffStat `json:"stat"`
+	// Sync is only computed with tree --show-syncs
+	Sync *SyncReport `json:"sync,omitempty"`
+	// Status and PullRequest are only computed for the graph formats
+	Status      *branchStatus `json:"-"`
+	PullRequest string        `json:"-"`
+}
+
+// statusWorkers bounds the number of branches computed at the same time,
+// each one runs a few git processes.
+const statusWorkers = 8
+
+// statusReport computes the status of each branch of the current stack.
+func (sm StacksManager) statusReport(withLastCommit bool) StatusReport {
+	return sm.stackStatusReport(sm.stacks.CurrentStack, withLastCommit)
+}
+
+// stackStatusReport computes the status of each branch of a stack.
+// The branches are computed concurrently, but reported in the stack order.
+func (sm StacksManager) stackStatusReport(stackName string, withLastCommit bool) StatusReport {
+	data := *sm.stacks
+	branches, _ := data.GetBranchesByName(stackName)
+	report := StatusReport{Stack: stackName, Branches: make([]BranchReport, len(branches))}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(statusWorkers, len(branches)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				report.Branches[i] = sm.branchReport(branches, i, withLastCommit)
+			}
+		}()
+	}
+	for i := range branches {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return report
+}
+
+func (sm StacksManager) branchReport(branches []string, i int, withLastCommit bool) BranchReport {
+	branch := branches[i]
+	branchReport := BranchReport{
+		Position: i + 1,
+		Name:     branch,
+		Status:   defaultBranchStatus(),
+	}
+
+	if ahead, behind, err := sm.aheadBehind(branch, sm.remote()+"/"+branch); err == nil {
+		branchReport.Status.setRemote(ahead, behind)
+		branchReport.Remote = &AheadBehindReport{Ahead: ahead, Behind: behind}
+	}
+
+	if i == 0 {
+		if defaultBranch, err := sm.defaultBranchWithRemote(); err == nil {
+			branchReport.Parent = defaultBranch
+			if _, behind, err := sm.aheadBehind(branch, defaultBranch); err == nil {
+				branchReport.Status.setBehindDefaultBranch(behind)
+			}
+		}
+	} else {
+		branchReport.Parent = branches[i-1]
+		if _, behind, err := sm.aheadBehind(branch, branches[i-1]); err == nil {
+			branchReport.Status.setBehindParent(behind)
+		}
+	}
+
+	if withLastCommit {
+		if commit, err := sm.lastCommit(branch); err == nil {
+			branchReport.LastCommit = &commit
+		}
+	}
+	return branchReport
+}
+
+func (sm StacksManager) listReport() ListReport {
+	data := *sm.stacks
+	report := ListReport{CurrentStack: data.CurrentStack, Stacks: []StackReport{}}
+	for i, stack := range data.Stacks {
+		branches := stack.Branches
+		if branches == nil {
+			branches = []string{}
+		}
+		report.Stacks = append(report.Stacks, StackReport{
+			Position: i + 1,
+			Name:     stack.Name,
+			Branches: branches,
+		})
+	}
+	return report
+}
+
+// treeReport computes the commits of each branch of a stack, filtered by the options.
+// For the graphs, it adds the status and the pull request number of each branch.
+func (sm StacksManager) treeReport(stackName string, options TreeOptions) (TreeReport, error) {
+	branches, _ := sm.stacks.GetBranchesByName(stackName)
+	defaultBranch, err := sm.defaultBranchWithRemote()
+	if err != nil {
+		return TreeReport{}, err
+	}
+
+	report := TreeReport{
+		Stack:           stackName,
+		DefaultBranch:   defaultBranch,
+		Branches:        []TreeBranchReport{},
+		CommitsFiltered: options.Author != "" || options.Since != "",
+	}
+
+	filter := commitFilter{
+		author: options.Author,
+		since:  options.Since,
+		paths:  options.Paths,
+		stat:   options.Stat,
+		merges: options.ShowSyncs,
+	}
+	parent := defaultBranch
+	for _, branch := range branches {
+		commits, err := sm.filteredCommitsBetweenBranches(parent, branch, filter)
+		if err != nil {
+			return TreeReport{}, err
+		}
+		if commits == nil {
+			commits = []CommitReport{}
+		}
+		stat, err := sm.diffStat(parent, branch, options.Paths)
+		if err != nil {
+			return TreeReport{}, err
+		}
+		branchReport := TreeBranchReport{
+			Name:    branch,
+			Parent:  parent,
+			Commits: commits,
+			Stat:    stat,
+		}
+		if options.ShowSyncs {
+			branchReport.Sync, err = sm.syncReport(parent, branch)
+			if err != nil {
+				return TreeReport{}, err
+			}
+		}
+		report.Branches = append(report.Branches, branchReport)
+		parent = branch
+	}
+
+	if options.Format == TreeFormatDot || options.Format == TreeFormatMermaid {
+		sm.addGraphDetails(&report)
+	}
+	return report, nil
+}
+
+func (sm StacksManager) syncReport(parent string, branch string) (*SyncReport, error) {
+	lastSync, err := sm.lastSyncMerge(parent, branch)
+	if err != nil {
+		return nil, err
+	}
+	_, behind, err := sm.aheadBehind(branch, parent)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncReport{LastSync: lastSync, BehindParentCount: behind}, nil
+}
+
+// addGraphDetails adds the status of the branches, and their pull request numbers when GH-CLI is available.
+func (sm StacksManager) addGraphDetails(report *TreeReport) {
+	status := sm.stackStatusReport(report.Stack, false)
+	withPullRequests := sm.ghExecutor != nil && sm.ghCliConfigure() == nil
+	for i := range report.Branches {
+		report.Branches[i].Status = &status.Branches[i].Status
+		if !withPullRequests {
+			continue
+		}
+		if prNumber, err := sm.ghPrNumber(report.Branches[i].Name); err == nil {
+			report.Branches[i].PullRequest = prNumber
+		}
+	}
+}
+
+func (sm StacksManager) printJSON(report interface{}) error {
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.New("failed to marshal JSON\n" + err.Error())
+	}
+	sm.printer.Println(string(jsonData))
+	return nil
+}
@@ -0,0 +1,192 @@
+package stack
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		err := os.WriteFile(path, []byte(got), 0644)
+		if err != nil {
+			t.Fatalf("failed to update golden file: %s", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %s", err)
+	}
+	if got != string(want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func jsonStacksManagerForTest(gitExecutor cliExecutorStub, messageReceived *[]string) StacksManager {
+	stacksManager := StacksManagerForTest(gitExecutor, messageReceived)
+	stacksManager.output = OutputJSON
+	return stacksManager
+}
+
+func TestStacksManager_CurrentStackStatus_JSON(t *testing.T) {
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			switch strings.Join(command, " ") {
+			case "symbolic-ref refs/remotes/origin/HEAD --short":
+				return "origin/main", nil
+			case "rev-list --left-right --count branch1...origin/branch1":
+				return "1\t2", nil
+			case "rev-list --left-right --count branch1...origin/main":
+				return "3\t12", nil
+			case "rev-list --left-right --count branch2...origin/branch2":
+				return "fatal: ambiguous argument", fmt.Errorf("git command error")
+			case "rev-list --left-right --count branch2...branch1":
+				return "4\t1", nil
+			}
+			if command[0] == "log" {
+				return "a1b2c3d\x1fLast commit of " + command[len(command)-1] +
+					"\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f3 minutes ago", nil
+			}
+			return "", nil
+		},
+	}
+	var messageReceived []string
+	stacksManager := jsonStacksManagerForTest(gitExecutor, &messageReceived)
+
+	err := stacksManager.CurrentStackStatus(false)
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+
+	assertGolden(t, "status", stacksManager.printerMessage())
+}
+
+func TestStacksManager_List_JSON(t *testing.T) {
+	var messageReceived []string
+	stacksManager := jsonStacksManagerForTest(cliExecutorStub{}, &messageReceived)
+
+	err := stacksManager.List()
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+
+	assertGolden(t, "list", stacksManager.printerMessage())
+}
+
+func TestStacksManager_Tree_JSON(t *testing.T) {
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			switch strings.Join(command, " ") {
+			case "symbolic-ref refs/remotes/origin/HEAD --short":
+				return "origin/main", nil
+			case "log --no-merges --reverse --right-only " + commitFormat + " origin/main...branch1":
+				return "abc1234\x1fAdd feature - part 1\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f2 days ago\n" +
+					"def5678\x1fFix feature\x1fJane Doe\x1f2024-01-02T10:00:00-05:00\x1f1 day ago", nil
+			case "diff --shortstat origin/main...branch1":
+				return " 3 files changed, 25 insertions(+), 4 deletions(-)", nil
+			}
+			return "", nil
+		},
+	}
+	var messageReceived []string
+	stacksManager := jsonStacksManagerForTest(gitExecutor, &messageReceived)
+
+	err := stacksManager.Tree(TreeOptions{})
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+
+	assertGolden(t, "tree", stacksManager.printerMessage())
+}
+
+// latencyExecutorStub simulates a slow git in a big repository.
+func latencyExecutorStub(latency func(command ...string) time.Duration) cliExecutorStub {
+	return cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			time.Sleep(latency(command...))
+			switch command[0] {
+			case "rev-list":
+				return "0\t1", nil
+			case "symbolic-ref":
+				return "origin/main", nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func stacksManagerWithBranches(gitExecutor cliExecutorStub, count int) StacksManager {
+	var messageReceived []string
+	stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+	stacksManager.cache = newRepoCache()
+	var branches []string
+	for i := 1; i <= count; i++ {
+		branches = append(branches, fmt.Sprintf("feature/%d", i))
+	}
+	stacksManager.stacks.Stacks[0].Branches = branches
+	return stacksManager
+}
+
+func TestStacksManager_statusReport(t *testing.T) {
+	t.Run("keeps the stack order", func(t *testing.T) {
+		// The first branches are the slowest
+		gitExecutor := latencyExecutorStub(func(command ...string) time.Duration {
+			var n int
+			fmt.Sscanf(command[len(command)-1], "feature/%d", &n)
+			return time.Duration(10-n) * time.Millisecond
+		})
+		stacksManager := stacksManagerWithBranches(gitExecutor, 10)
+
+		report := stacksManager.statusReport(false)
+
+		for i, branch := range report.Branches {
+			want := fmt.Sprintf("feature/%d", i+1)
+			if branch.Name != want || branch.Position != i+1 {
+				t.Errorf("got %d. %s, want %d. %s", branch.Position, branch.Name, i+1, want)
+			}
+		}
+	})
+
+	t.Run("looks up the default branch once", func(t *testing.T) {
+		var mutex sync.Mutex
+		var defaultBranchLookups int
+		gitExecutor := latencyExecutorStub(func(command ...string) time.Duration {
+			if command[0] == "symbolic-ref" {
+				mutex.Lock()
+				defaultBranchLookups++
+				mutex.Unlock()
+			}
+			return 0
+		})
+		stacksManager := stacksManagerWithBranches(gitExecutor, 10)
+
+		stacksManager.statusReport(false)
+		stacksManager.statusReport(false)
+
+		if defaultBranchLookups != 1 {
+			t.Errorf("got %d, want %d", defaultBranchLookups, 1)
+		}
+	})
+}
+
+func BenchmarkStacksManager_statusReport(b *testing.B) {
+	gitExecutor := latencyExecutorStub(func(command ...string) time.Duration {
+		return 20 * time.Millisecond
+	})
+	stacksManager := stacksManagerWithBranches(gitExecutor, 10)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stacksManager.statusReport(true)
+	}
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/cliexec"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// replayStacksManager returns a manager replaying testdata/fixtures/<name>.json.
+// The fixtures are recorded from a real repository with the hidden flag --record, e.g.
+//
+//	gostacking --record status.json status
+//
+// The repository has the stack1 of stacksDataMock: branch1 from main, then branch2,
+// each pushed to a bare origin.
+//
+// The test fails when a command is not in the fixture or when one of the fixture is not run.
+func replayStacksManager(t *testing.T, name string, messageReceived *[]string) StacksManager {
+	fixture, err := cliexec.LoadFixture(filepath.Join("testdata", "fixtures", name+".json"))
+	if err != nil {
+		t.Fatalf("failed to load fixture %s: %s", name, err)
+	}
+	replayer := cliexec.NewReplayer(fixture)
+	t.Cleanup(func() {
+		if err := replayer.Err(); err != nil {
+			t.Error(err)
+		}
+		for _, interaction := range replayer.Remaining() {
+			t.Errorf("command not run: %s %s", interaction.Command, strings.Join(interaction.Args, " "))
+		}
+	})
+
+	stacksManager := StacksManagerForTest(replayer.Executor("git"), messageReceived)
+	stacksManager.ghExecutor = replayer.Executor("gh")
+	return stacksManager
+}
+
+func TestScenario_SyncWithPush(t *testing.T) {
+	var messageReceived []string
+	stacksManager := replayStacksManager(t, "sync_push", &messageReceived)
+
+	err := stacksManager.Sync(true, false)
+
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+	want := fmt.Sprintf(
+		`Branch: %s
+	Checkout...
+	Pull...
+	Merging %s
+	Pushing...`,
+		color.Yellow("branch2"),
+		color.Yellow("branch1"),
+	)
+	if !strings.Contains(stacksManager.printerMessage(), want) {
+		t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+	}
+}
+
+func TestScenario_SyncWithConflict(t *testing.T) {
+	var messageReceived []string
+	stacksManager := replayStacksManager(t, "sync_conflict", &messageReceived)
+
+	err := stacksManager.Sync(false, false)
+
+	if err == nil {
+		t.Fatalf("got none, want Error")
+	}
+	want := "failed to merge\nAuto-merging b.txt\nCONFLICT (content): Merge conflict in b.txt"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("got \"%s\", want \"%s\"", err.Error(), want)
+	}
+}
+
+func TestScenario_Status(t *testing.T) {
+	var messageReceived []string
+	stacksManager := replayStacksManager(t, "status", &messageReceived)
+
+	err := stacksManager.CurrentStackStatus(false)
+
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+	want := fmt.Sprintf(
+		`Current stack: %s
+Branches:
+1. %s
+2. %s
+
+`,
+		color.Green("stack1"),
+		color.Yellow("branch1"),
+		color.Yellow("branch2"),
+	)
+	if stacksManager.printerMessage() != want {
+		t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+	}
+}
@@ -0,0 +1,207 @@
+package stack
+
+import (
+	"errors"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"os"
+	"slices"
+	"strings"
+)
+
+type SplitOptions struct {
+	// Branch is the name or the number of a branch of the current stack, the current branch when empty
+	Branch string
+	// At is the last commit of the new branch, the commits after it stay in the branch
+	At string
+	// Paths moves the changes of the files matching the globs to the new branch instead of cutting at a commit
+	Paths []string
+	// Name of the new branch, <branch>-part1 when empty
+	Name string
+}
+
+// Split cuts a branch of the current stack in two. The new branch, with the first part of the changes,
+// is inserted before it in the stack.
+// At a commit, the new branch starts at it and the branch is left untouched since its parent now has those commits.
+// By paths, the changes of the matching files are committed on the new branch from the parent
+// (its merge base with the branch), then merged in the branch like a sync so the history is not rewritten.
+func (sm StacksManager) Split(options SplitOptions) error {
+	if (options.At == "") == (len(options.Paths) == 0) {
+		return errors.New("split needs either --at or --path")
+	}
+
+	sm.stacks.LoadStacks()
+	data := *sm.stacks
+	stack, err := data.GetStackByName(data.CurrentStack)
+	if err != nil {
+		return err
+	}
+	i, err := sm.stackBranchIndex(stack.Branches, options.Branch)
+	if err != nil {
+		return err
+	}
+	branch := stack.Branches[i]
+	parent, err := sm.stackParent(stack.Branches, i)
+	if err != nil {
+		return err
+	}
+
+	name := options.Name
+	if name == "" {
+		name = branch + "-part1"
+	}
+	if sm.branchExists(name) {
+		return errors.New("branch " + color.Yellow(name) + " already exists, choose another name with --name")
+	}
+
+	branches := slices.Insert(slices.Clone(stack.Branches), i, name)
+	if options.At != "" {
+		err = sm.splitAt(parent, branch, options.At, name)
+	} else {
+		err = sm.splitPaths(data.CurrentStack, branches, i+1, parent, options.Paths)
+	}
+	if err != nil {
+		return err
+	}
+
+	stack.Branches = branches
+	data.SaveStacks()
+	sm.printer.Println("Branch", color.Yellow(branch), "split,", color.Yellow(name), "inserted before it in", color.Green(data.CurrentStack))
+	return nil
+}
+
+// ListCommitsForSplit is the commits of a branch of the current stack where it can be split,
+// like "abc1234 Add feature", oldest first. The last commit is not one of them, nothing would stay in the branch.
+func (sm StacksManager) ListCommitsForSplit(branchNameOrNumber string) []string {
+	sm.stacks.LoadStacks()
+	branches, _ := sm.stacks.GetBranchesByName(sm.stacks.CurrentStack)
+	i, err := sm.stackBranchIndex(branches, branchNameOrNumber)
+	if err != nil {
+		return nil
+	}
+	parent, err := sm.stackParent(branches, i)
+	if err != nil {
+		return nil
+	}
+	commits, err := sm.commitsBetweenBranches(parent, branches[i])
+	if err != nil || len(commits) == 0 {
+		return nil
+	}
+
+	var items []string
+	for _, commit := range commits[:len(commits)-1] {
+		items = append(items, commit.Hash+" "+commit.Subject)
+	}
+	return items
+}
+
+func (sm StacksManager) splitAt(parent string, branch string, at string, name string) error {
+	output, err := sm.gitExecutor.Exec("rev-parse", "--verify", "--quiet", at+"^{commit}")
+	if err != nil {
+		return errors.New("commit " + at + " not found")
+	}
+	sha := strings.TrimSpace(output)
+
+	if !sm.isAncestor(sha, branch) || sm.isAncestor(sha, parent) {
+		return errors.New("commit " + at + " is not a commit of " + color.Yellow(branch) + " since " + color.Yellow(parent))
+	}
+	if sha == sm.commitSha(branch) {
+		return errors.New("commit " + at + " is the last commit of " + color.Yellow(branch) + ", nothing would stay in it")
+	}
+
+	output, err = sm.gitExecutor.Exec("branch", name, sha)
+	if err != nil {
+		return errors.New("failed to create branch " + color.Yellow(name) + "\n" + output)
+	}
+	return nil
+}
+
+// splitPaths moves the changes of the paths of the branch at index i to the new branch before it,
+// branches are the ones of the stack with the new branch. When the changes can't be committed
+// on the new branch or it can't be merged in the branch, it is removed.
+func (sm StacksManager) splitPaths(stackName string, branches []string, i int, parent string, paths []string) error {
+	branch, name := branches[i], branches[i-1]
+	if sm.unstagedChanges() {
+		return errors.New("unstaged changes, please commit or stash them")
+	}
+	currentBranch, err := sm.currentBranchName()
+	if err != nil {
+		return err
+	}
+
+	// From the merge base, the changes of the parent not merged yet are not moved.
+	// The patch applies on it, then merging it back in the branch can't conflict
+	output, err := sm.gitExecutor.Exec("merge-base", parent, branch)
+	if err != nil {
+		return errors.New("failed to find the merge base of " + parent + " and " + branch + "\n" + output)
+	}
+	base := strings.TrimSpace(output)
+
+	// Plumbing with explicit options, the diff config of the user (prefixes, color, external tool) can't change the patch
+	patch, err := sm.gitExecutor.Exec(append([]string{
+		"diff-tree", "-p", "--binary", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", base, branch,
+	}, pathspecs(paths)...)...)
+	if err != nil {
+		return errors.New("failed to diff " + branch + "\n" + patch)
+	}
+	if strings.TrimSpace(patch) == "" {
+		return errors.New("no changes of " + color.Yellow(branch) + " match the paths " + strings.Join(paths, " "))
+	}
+	patchFile, err := os.CreateTemp("", "gostacking-split-*.patch")
+	if err != nil {
+		return errors.New("failed to create the patch file\n" + err.Error())
+	}
+	defer os.Remove(patchFile.Name())
+	_, err = patchFile.WriteString(patch + "\n")
+	patchFile.Close()
+	if err != nil {
+		return errors.New("failed to write the patch file\n" + err.Error())
+	}
+
+	sm.printer.Println("Creating", color.Yellow(name), "from", color.Yellow(parent)+"...")
+	output, err = sm.gitExecutor.Exec("checkout", "-b", name, base)
+	if err != nil {
+		return errors.New("failed to create branch " + color.Yellow(name) + "\n" + output)
+	}
+	for _, command := range [][]string{
+		{"apply", "--index", patchFile.Name()},
+		{"commit", "-m", "Split " + strings.Join(paths, ", ") + " out of " + branch},
+	} {
+		output, err := sm.gitExecutor.Exec(command...)
+		if err != nil {
+			err = errors.New("failed to " + command[0] + " on " + color.Yellow(name) + "\n" + output)
+			return sm.removeSplitBranch(name, currentBranch, err)
+		}
+	}
+
+	sm.printer.Println("Merging", color.Yellow(name), "in", color.Yellow(branch)+"...")
+	err = sm.checkout(branch)
+	if err == nil {
+		err = sm.merge(branch, name)
+	}
+	if err != nil {
+		return sm.removeSplitBranch(name, currentBranch, err)
+	}
+	sm.runPostMergeHook(stackName, branches, i, name)
+	return sm.checkout(currentBranch)
+}
+
+// removeSplitBranch goes back to currentBranch and deletes the new branch of a split that failed with cause.
+// A merge of the new branch stopped halfway is aborted, otherwise the changes staged on the new branch
+// are discarded, there were no unstaged changes before the split.
+func (sm StacksManager) removeSplitBranch(name string, currentBranch string, cause error) error {
+	undo := []string{"reset", "--hard"}
+	if _, err := sm.gitExecutor.Exec("rev-parse", "-q", "--verify", "MERGE_HEAD"); err == nil {
+		undo = []string{"merge", "--abort"}
+	}
+	for _, command := range [][]string{
+		undo,
+		{"checkout", currentBranch},
+		{"branch", "-D", name},
+	} {
+		output, err := sm.gitExecutor.Exec(command...)
+		if err != nil {
+			return errors.New(cause.Error() + "\nfailed to remove " + color.Yellow(name) + "\n" + output)
+		}
+	}
+	return cause
+}
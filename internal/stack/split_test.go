@@ -0,0 +1,252 @@
+package stack
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStacksManager_Split(t *testing.T) {
+	t.Run("at a commit", func(t *testing.T) {
+		var gitCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				switch strings.Join(command, " ") {
+				case "rev-parse --abbrev-ref HEAD":
+					return "branch2", nil
+				case "rev-parse --verify --quiet abc1234^{commit}":
+					return "abc1234abc1234abc1234abc1234abc1234abc12", nil
+				case "rev-parse branch2":
+					return "def5678def5678def5678def5678def5678def56", nil
+				case "rev-parse --verify branch2-part1",
+					"merge-base --is-ancestor abc1234abc1234abc1234abc1234abc1234abc12 branch1":
+					return "", errors.New("exit status 1")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{At: "abc1234"})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if !slices.Contains(gitCommands, "branch branch2-part1 abc1234abc1234abc1234abc1234abc1234abc12") {
+			t.Errorf("got %v, want branch branch2-part1", gitCommands)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		want := []string{"branch1", "branch2-part1", "branch2"}
+		if !reflect.DeepEqual(branches, want) {
+			t.Errorf("got %v, want %v", branches, want)
+		}
+	})
+
+	t.Run("by paths", func(t *testing.T) {
+		var gitCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				switch strings.Join(command, " ") {
+				case "rev-parse --abbrev-ref HEAD":
+					return "branch2", nil
+				case "rev-parse --verify other":
+					return "", errors.New("exit status 1")
+				case "diff-tree -p --binary --no-color --no-ext-diff --src-prefix=a/ --dst-prefix=b/ aaa1111 branch2 -- :(glob)api/**":
+					return "diff --git a/api/a.go b/api/a.go", nil
+				case "merge-base branch1 branch2":
+					return "aaa1111", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager, runs := hooksStacksManager(gitExecutor, map[string][]string{HookPostMerge: {"npm", "install"}}, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{Branch: "2", Paths: []string{"api/**"}, Name: "other"})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		for _, want := range []string{
+			"checkout -b other aaa1111",
+			"commit -m Split api/** out of branch2",
+			"checkout branch2",
+			"merge other -m Merge branch other into branch2 (gostacking)",
+		} {
+			if !slices.Contains(gitCommands, want) {
+				t.Errorf("got %v, want %s", gitCommands, want)
+			}
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		want := []string{"branch1", "other", "branch2"}
+		if !reflect.DeepEqual(branches, want) {
+			t.Errorf("got %v, want %v", branches, want)
+		}
+		if len(*runs) != 1 || !slices.Contains((*runs)[0].env, "GOSTACKING_PARENT=other") {
+			t.Errorf("got %v, want post-merge of branch2 with other", *runs)
+		}
+	})
+
+	t.Run("by paths when the commit fails", func(t *testing.T) {
+		var gitCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				switch strings.Join(command, " ") {
+				case "rev-parse --abbrev-ref HEAD":
+					return "branch1", nil
+				case "rev-parse --verify other":
+					return "", errors.New("exit status 1")
+				case "diff-tree -p --binary --no-color --no-ext-diff --src-prefix=a/ --dst-prefix=b/ aaa1111 branch2 -- :(glob)api/**":
+					return "diff --git a/api/a.go b/api/a.go", nil
+				case "merge-base branch1 branch2":
+					return "aaa1111", nil
+				case "commit -m Split api/** out of branch2":
+					return "pre-commit hook failed", errors.New("exit status 1")
+				case "rev-parse -q --verify MERGE_HEAD":
+					return "", errors.New("exit status 1")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{Branch: "2", Paths: []string{"api/**"}, Name: "other"})
+
+		if err == nil {
+			t.Fatalf("got none, want Error")
+		}
+		if !strings.Contains(err.Error(), "pre-commit hook failed") {
+			t.Errorf("got \"%s\", want \"%s\"", err.Error(), "pre-commit hook failed")
+		}
+		want := []string{"reset --hard", "checkout branch1", "branch -D other"}
+		if !reflect.DeepEqual(gitCommands[len(gitCommands)-3:], want) {
+			t.Errorf("got %v, want %v at the end", gitCommands, want)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		if !reflect.DeepEqual(branches, []string{"branch1", "branch2"}) {
+			t.Errorf("got %v, want %v", branches, []string{"branch1", "branch2"})
+		}
+	})
+
+	t.Run("by paths when the merge fails", func(t *testing.T) {
+		var gitCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				switch strings.Join(command, " ") {
+				case "rev-parse --abbrev-ref HEAD":
+					return "branch1", nil
+				case "rev-parse --verify other":
+					return "", errors.New("exit status 1")
+				case "diff-tree -p --binary --no-color --no-ext-diff --src-prefix=a/ --dst-prefix=b/ aaa1111 branch2 -- :(glob)api/**":
+					return "diff --git a/api/a.go b/api/a.go", nil
+				case "merge-base branch1 branch2":
+					return "aaa1111", nil
+				case "merge other -m Merge branch other into branch2 (gostacking)":
+					return "CONFLICT (content): Merge conflict in api/a.go", errors.New("exit status 1")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{Branch: "2", Paths: []string{"api/**"}, Name: "other"})
+
+		if err == nil {
+			t.Fatalf("got none, want Error")
+		}
+		want := []string{"rev-parse -q --verify MERGE_HEAD", "merge --abort", "checkout branch1", "branch -D other"}
+		if !reflect.DeepEqual(gitCommands[len(gitCommands)-4:], want) {
+			t.Errorf("got %v, want %v at the end", gitCommands, want)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		if !reflect.DeepEqual(branches, []string{"branch1", "branch2"}) {
+			t.Errorf("got %v, want %v", branches, []string{"branch1", "branch2"})
+		}
+	})
+
+	t.Run("at the last commit", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				switch strings.Join(command, " ") {
+				case "rev-parse --abbrev-ref HEAD":
+					return "branch2", nil
+				case "rev-parse --verify --quiet def5678^{commit}", "rev-parse branch2":
+					return "def5678def5678def5678def5678def5678def56", nil
+				case "rev-parse --verify branch2-part1":
+					return "", errors.New("exit status 1")
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{At: "def5678"})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("at a commit of the parent", func(t *testing.T) {
+		var gitCommands []string
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				gitCommands = append(gitCommands, strings.Join(command, " "))
+				switch strings.Join(command, " ") {
+				case "symbolic-ref refs/remotes/origin/HEAD --short":
+					return "origin/main", nil
+				case "rev-parse --verify --quiet abc1234^{commit}":
+					return "abc1234abc1234abc1234abc1234abc1234abc12", nil
+				case "rev-parse --verify other":
+					return "", errors.New("exit status 1")
+				// abc1234 is in branch1 but it was already in origin/main
+				case "merge-base --is-ancestor abc1234abc1234abc1234abc1234abc1234abc12 branch1",
+					"merge-base --is-ancestor abc1234abc1234abc1234abc1234abc1234abc12 origin/main":
+					return "", nil
+				}
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{Branch: "branch1", At: "abc1234", Name: "other"})
+
+		if err == nil {
+			t.Fatalf("got none, want Error")
+		}
+		if !strings.Contains(err.Error(), "is not a commit of") {
+			t.Errorf("got \"%s\", want \"%s\"", err.Error(), "is not a commit of")
+		}
+		if slices.Contains(gitCommands, "branch other abc1234abc1234abc1234abc1234abc1234abc12") {
+			t.Errorf("got %v, want no branch created", gitCommands)
+		}
+	})
+
+	t.Run("without --at nor --path", func(t *testing.T) {
+		gitExecutor := cliExecutorStub{
+			stubExec: func(command ...string) (string, error) {
+				t.Errorf("got %s, want no git command", command)
+				return "", nil
+			},
+		}
+		var messageReceived []string
+		stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+		err := stacksManager.Split(SplitOptions{})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 )
@@ -21,16 +22,31 @@ type Stack struct {
 	Branches []string `json:"branches"`
 }
 
+// InterruptedSync is where a sync stopped after an interruption (Ctrl-C)
+type InterruptedSync struct {
+	Stack  string `json:"stack"`
+	Branch string `json:"branch"`
+}
+
 type StacksData struct {
 	CurrentStack    string           `json:"currentStack"`
 	Stacks          []Stack          `json:"stacks"`
+	InterruptedSync *InterruptedSync `json:"interruptedSync,omitempty"`
 	StacksPersister StacksPersisting `json:"-"`
 }
 
-type StacksPersistingFile struct{}
+// StacksPersistingFile persists the stacks in the repository at Dir,
+// the current directory when empty.
+type StacksPersistingFile struct {
+	Dir string
+}
+
+func (s StacksPersistingFile) path() string {
+	return filepath.Join(s.Dir, stacksFile)
+}
 
 func (s StacksPersistingFile) LoadStacks(data *StacksData) {
-	jsonData, err := os.ReadFile(stacksFile)
+	jsonData, err := os.ReadFile(s.path())
 	// If the file does not exist, return an empty data
 	// Calling SaveStacks will create the file
 	if err != nil {
@@ -53,7 +69,7 @@ func (s StacksPersistingFile) SaveStacks(data StacksData) {
 		log.Fatal("Error marshaling JSON:", err)
 	}
 
-	err = os.WriteFile(stacksFile, jsonData, 0644)
+	err = os.WriteFile(s.path(), jsonData, 0644)
 	if err != nil {
 		log.Fatal("Error writing to file:", err)
 	}
@@ -102,3 +118,14 @@ func (data *StacksData) SetCurrentStack(stackName string) {
 	data.CurrentStack = stackName
 	data.SaveStacks()
 }
+
+// RenameBranch replaces the branch in every stack that contains it.
+func (data *StacksData) RenameBranch(oldName string, newName string) {
+	for i := range data.Stacks {
+		for j, branch := range data.Stacks[i].Branches {
+			if branch == oldName {
+				data.Stacks[i].Branches[j] = newName
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package stack
+
+import (
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"slices"
+	"strings"
+)
+
+const (
+	TreeFormatText    = "text"
+	TreeFormatDot     = "dot"
+	TreeFormatMermaid = "mermaid"
+)
+
+type TreeOptions struct {
+	// Format is TreeFormatText (default), TreeFormatDot or TreeFormatMermaid
+	Format string
+	// All shows every stack instead of the current one
+	All bool
+	// Stat shows the files changed, insertions and deletions of each commit
+	Stat bool
+	// Author, Since and Paths only show the commits of an author, more recent than a date
+	// or changing files matching one of the globs (the diff stat of the branches is only limited to the paths)
+	Author string
+	Since  string
+	Paths  []string
+	// ShowSyncs shows the merge commits, the last sync of each branch and if its parent moved since
+	ShowSyncs bool
+}
+
+// treeFormatters render the trees of the stacks for each format of `tree --format`.
+var treeFormatters = map[string]func(reports []TreeReport, currentStack string) string{
+	TreeFormatText:    formatTreeText,
+	TreeFormatDot:     formatTreeDot,
+	TreeFormatMermaid: formatTreeMermaid,
+}
+
+func formatTreeText(reports []TreeReport, currentStack string) string {
+	var trees []string
+	for _, report := range reports {
+		title := "Stack:"
+		if report.Stack == currentStack {
+			title = "Current stack:"
+		}
+		trees = append(trees, title+" "+color.Green(report.Stack)+" \n\n"+textTree(report))
+	}
+	return strings.Join(trees, "\n")
+}
+
+func textTree(report TreeReport) string {
+	treeOutput := ""
+	lastIndex := len(report.Branches)
+
+	for i, branch := range report.Branches {
+		branchColor := colorFunc(i)
+
+		if i == 0 {
+			treeOutput += branchColor("* "+branch.Name) + "\n"
+		} else {
+			treeOutput += pipesColors(i, false) + branchColor("* "+branch.Name) + "\n"
+		}
+		treeOutput += pipesColors(i+1, false) + textBranchSummary(len(branch.Commits), report.CommitsFiltered, branch.Stat) + "\n"
+		if branch.Sync != nil {
+			treeOutput += pipesColors(i+1, false) + textSync(*branch.Sync) + "\n"
+		}
+
+		for _, commit := range branch.Commits {
+			treeOutput += pipesColors(i+1, false) + color.DarkYellow(commit.Hash) + " " + commit.Subject + " - " + commit.RelativeDate
+			if commit.Stat != nil {
+				treeOutput += " (" + textStat(*commit.Stat) + ")"
+			}
+			if branch.Sync != nil && branch.Sync.LastSync != nil && branch.Sync.LastSync.Hash == commit.Hash {
+				treeOutput += " " + color.Teal("← last sync")
+			}
+			treeOutput += "\n"
+		}
+
+		if i != lastIndex-1 {
+			treeOutput += pipesColors(i+1, true)
+		}
+	}
+	return treeOutput
+}
+
+// textBranchSummary is like "3 commits, 2 files changed, +5 -1". When the commits are filtered,
+// it is like "1 matching commit, branch diff: 2 files changed, +5 -1" since the stat is the one of all the commits.
+func textBranchSummary(count int, filtered bool, stat DiffStat) string {
+	if !filtered {
+		return plural(count, "commit") + ", " + textStat(stat)
+	}
+	return plural(count, "matching commit") + ", branch diff: " + textStat(stat)
+}
+
+// textStat is like "2 files changed, +5 -1".
+func textStat(stat DiffStat) string {
+	return plural(stat.FilesChanged, "file") + " changed, " +
+		color.Green(fmt.Sprintf("+%d", stat.Insertions)) + " " + color.Red(fmt.Sprintf("-%d", stat.Deletions))
+}
+
+// textSync is like "Last sync 2 days ago, parent moved: 3 commits to merge".
+func textSync(sync SyncReport) string {
+	text := "Never synced"
+	if sync.LastSync != nil {
+		text = "Last sync " + sync.LastSync.RelativeDate
+	}
+	if sync.BehindParentCount == 0 {
+		return text + ", up to date with its parent"
+	}
+	return text + ", " + color.Red("parent moved: "+plural(sync.BehindParentCount, "commit")+" to merge")
+}
+
+// graphLabel is the lines of a branch node: its name, number of commits, status and pull request.
+func graphLabel(branch TreeBranchReport) []string {
+	label := []string{branch.Name, plural(len(branch.Commits), "commit")}
+	if branch.Status != nil {
+		if symbols := strings.TrimSpace(color.Strip(branch.Status.Symbols())); symbols != "" {
+			label = append(label, symbols)
+		}
+	}
+	if branch.PullRequest != "" {
+		label = append(label, "#"+branch.PullRequest)
+	}
+	return label
+}
+
+// formatTreeDot renders a Graphviz graph, each stack in a cluster
+// with the default branch as root, e.g. `gostacking tree --format dot | dot -Tsvg`.
+func formatTreeDot(reports []TreeReport, currentStack string) string {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+
+	lines := []string{"digraph gostacking {", "  node [shape=box];"}
+	roots := map[string]bool{}
+	for _, report := range reports {
+		if !roots[report.DefaultBranch] {
+			roots[report.DefaultBranch] = true
+			lines = append(lines, "  "+quote(report.DefaultBranch)+" [shape=ellipse];")
+		}
+	}
+
+	// A branch in more than one stack is a single node, in the cluster of the first stack
+	declared := map[string]bool{}
+	var edges []string
+	for i, report := range reports {
+		lines = append(lines, fmt.Sprintf("  subgraph cluster_%d {", i), "    label="+quote(report.Stack)+";")
+		for _, branch := range report.Branches {
+			edge := "  " + quote(branch.Parent) + " -> " + quote(branch.Name) + ";"
+			if !slices.Contains(edges, edge) {
+				edges = append(edges, edge)
+			}
+			if declared[branch.Name] {
+				continue
+			}
+			declared[branch.Name] = true
+			label := strings.Join(graphLabel(branch), `\n`)
+			lines = append(lines, "    "+quote(branch.Name)+" [label="+`"`+strings.ReplaceAll(label, `"`, `\"`)+`"`+"];")
+		}
+		lines = append(lines, "  }")
+	}
+	lines = append(lines, edges...)
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// formatTreeMermaid renders a Mermaid flowchart, each stack in a subgraph
+// with the default branch as root, to paste in a Markdown document.
+func formatTreeMermaid(reports []TreeReport, currentStack string) string {
+	// Branch names can't be Mermaid ids, the nodes are numbered
+	ids := map[string]string{}
+	id := func(branch string) string {
+		if _, ok := ids[branch]; !ok {
+			ids[branch] = fmt.Sprintf("n%d", len(ids))
+		}
+		return ids[branch]
+	}
+	text := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+	}
+
+	lines := []string{"flowchart TD"}
+	for _, report := range reports {
+		if _, ok := ids[report.DefaultBranch]; !ok {
+			lines = append(lines, "  "+id(report.DefaultBranch)+"(["+text(report.DefaultBranch)+"])")
+		}
+	}
+
+	var edges []string
+	for i, report := range reports {
+		lines = append(lines, fmt.Sprintf("  subgraph s%d[%s]", i, text(report.Stack)))
+		for _, branch := range report.Branches {
+			if _, ok := ids[branch.Name]; !ok {
+				lines = append(lines, "    "+id(branch.Name)+"["+text(strings.Join(graphLabel(branch), "<br/>"))+"]")
+			}
+			edge := "  " + id(branch.Parent) + " --> " + id(branch.Name)
+			if !slices.Contains(edges, edge) {
+				edges = append(edges, edge)
+			}
+		}
+		lines = append(lines, "  end")
+	}
+	lines = append(lines, edges...)
+	return strings.Join(lines, "\n")
+}
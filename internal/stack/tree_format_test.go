@@ -0,0 +1,284 @@
+package stack
+
+import (
+	"encoding/json"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func graphReportsForTest() []TreeReport {
+	return []TreeReport{
+		{
+			Stack:         "stack1",
+			DefaultBranch: "origin/main",
+			Branches: []TreeBranchReport{
+				{Name: "branch1", Parent: "origin/main", Commits: []CommitReport{{Hash: "abc1234"}}, PullRequest: "12"},
+				{Name: "branch2", Parent: "branch1", Commits: []CommitReport{{Hash: "def5678"}, {Hash: "aaa1111"}}, Status: &branchStatus{HasDiff: true, BehindParentCount: 1}},
+			},
+		},
+		{
+			Stack:         `my "stack"`,
+			DefaultBranch: "origin/main",
+			Branches: []TreeBranchReport{
+				{Name: "branch1", Parent: "origin/main", Commits: []CommitReport{{Hash: "abc1234"}}},
+				{Name: "branch3", Parent: "branch1", Commits: []CommitReport{}},
+			},
+		},
+	}
+}
+
+func TestFormatTreeDot(t *testing.T) {
+	got := formatTreeDot(graphReportsForTest(), "stack1")
+
+	want := `digraph gostacking {
+  node [shape=box];
+  "origin/main" [shape=ellipse];
+  subgraph cluster_0 {
+    label="stack1";
+    "branch1" [label="branch1\n1 commit\n#12"];
+    "branch2" [label="branch2\n2 commits\n⇣1"];
+  }
+  subgraph cluster_1 {
+    label="my \"stack\"";
+    "branch3" [label="branch3\n0 commits"];
+  }
+  "origin/main" -> "branch1";
+  "branch1" -> "branch2";
+  "branch1" -> "branch3";
+}`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatTreeMermaid(t *testing.T) {
+	got := formatTreeMermaid(graphReportsForTest(), "stack1")
+
+	want := `flowchart TD
+  n0(["origin/main"])
+  subgraph s0["stack1"]
+    n1["branch1<br/>1 commit<br/>#12"]
+    n2["branch2<br/>2 commits<br/>⇣1"]
+  end
+  subgraph s1["my #quot;stack#quot;"]
+    n3["branch3<br/>0 commits"]
+  end
+  n0 --> n1
+  n1 --> n2
+  n1 --> n3`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func treeStacksManagerForTest(messageReceived *[]string) StacksManager {
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			switch command[0] {
+			case "symbolic-ref":
+				return "origin/main", nil
+			case "rev-list":
+				return "0\t0", nil
+			case "log":
+				return "abcdef\x1fSome commit message\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f3 minutes ago", nil
+			}
+			return "", nil
+		},
+	}
+	stacksManager := StacksManagerForTest(gitExecutor, messageReceived)
+	stacksManager.ghExecutor = cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			if command[0] == "pr" && command[2] == "branch1" {
+				return "42", nil
+			}
+			return "", nil
+		},
+	}
+	return stacksManager
+}
+
+func TestStacksManager_Tree_Formats(t *testing.T) {
+	t.Run("mermaid with the pull requests", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := treeStacksManagerForTest(&messageReceived)
+
+		err := stacksManager.Tree(TreeOptions{Format: TreeFormatMermaid})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		want := `n1["branch1<br/>1 commit<br/>#42"]`
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+		if strings.Contains(stacksManager.printerMessage(), "branch3") {
+			t.Errorf("got \"%s\", want only stack1", stacksManager.printerMessage())
+		}
+	})
+
+	t.Run("all the stacks as text", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := treeStacksManagerForTest(&messageReceived)
+
+		err := stacksManager.Tree(TreeOptions{All: true})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		for _, want := range []string{"Current stack: ", "Stack: ", "* branch3"} {
+			if !strings.Contains(stacksManager.printerMessage(), want) {
+				t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+			}
+		}
+	})
+
+	t.Run("all the stacks as JSON", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := treeStacksManagerForTest(&messageReceived)
+		stacksManager.output = OutputJSON
+
+		err := stacksManager.Tree(TreeOptions{All: true})
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		var reports []TreeReport
+		err = json.Unmarshal([]byte(stacksManager.printerMessage()), &reports)
+		if err != nil || len(reports) != 2 {
+			t.Errorf("got %s, want 2 trees", stacksManager.printerMessage())
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := treeStacksManagerForTest(&messageReceived)
+
+		err := stacksManager.Tree(TreeOptions{Format: "svg"})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+
+	t.Run("graph format with JSON output", func(t *testing.T) {
+		var messageReceived []string
+		stacksManager := treeStacksManagerForTest(&messageReceived)
+		stacksManager.output = OutputJSON
+
+		err := stacksManager.Tree(TreeOptions{Format: TreeFormatDot})
+
+		if err == nil {
+			t.Errorf("got none, want Error")
+		}
+	})
+}
+
+func TestStacksManager_Tree_Filters(t *testing.T) {
+	var gitCommands []string
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			gitCommands = append(gitCommands, strings.Join(command, " "))
+			switch command[0] {
+			case "symbolic-ref":
+				return "origin/main", nil
+			case "log":
+				return "abcdef\x1fSome commit message\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f3 minutes ago\n" +
+					" 2 files changed, 3 insertions(+), 1 deletion(-)\n", nil
+			case "diff":
+				return " 2 files changed, 3 insertions(+), 1 deletion(-)", nil
+			}
+			return "", nil
+		},
+	}
+	var messageReceived []string
+	stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+	err := stacksManager.Tree(TreeOptions{Stat: true, Author: "John", Since: "2 weeks ago", Paths: []string{"internal/**", "*.md"}})
+
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+	for _, want := range []string{
+		"log --no-merges --reverse --right-only " + commitFormat + " --author=John --since=2 weeks ago --shortstat origin/main...branch1 -- :(glob)internal/** :(glob)*.md",
+		"diff --shortstat branch1...branch2 -- :(glob)internal/** :(glob)*.md",
+	} {
+		if !slices.Contains(gitCommands, want) {
+			t.Errorf("got %v, want %s", gitCommands, want)
+		}
+	}
+	want := color.DarkYellow("abcdef") + " Some commit message - 3 minutes ago (2 files changed, " + color.Green("+3") + " " + color.Red("-1") + ")"
+	if !strings.Contains(stacksManager.printerMessage(), want) {
+		t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+	}
+	// The stat of the branch is not limited to the commits of the author
+	want = "1 matching commit, branch diff: 2 files changed, " + color.Green("+3") + " " + color.Red("-1")
+	if !strings.Contains(stacksManager.printerMessage(), want) {
+		t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+	}
+}
+
+func TestParseShortStat(t *testing.T) {
+	tests := []struct {
+		line string
+		want DiffStat
+	}{
+		{" 2 files changed, 5 insertions(+), 1 deletion(-)", DiffStat{2, 5, 1}},
+		{" 1 file changed, 1 insertion(+)", DiffStat{1, 1, 0}},
+		{" 1 file changed, 3 deletions(-)", DiffStat{1, 0, 3}},
+		{"", DiffStat{}},
+	}
+	for _, test := range tests {
+		got := parseShortStat(test.line)
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestStacksManager_Tree_ShowSyncs(t *testing.T) {
+	var gitCommands []string
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			gitCommands = append(gitCommands, strings.Join(command, " "))
+			switch {
+			case command[0] == "symbolic-ref":
+				return "origin/main", nil
+			case command[0] == "rev-list" && command[len(command)-1] == "branch2...branch1":
+				return "0\t3", nil
+			case command[0] == "rev-list":
+				return "0\t0", nil
+			case command[0] == "log" && command[1] == "--merges" && command[len(command)-1] == "branch1..branch2":
+				return "bbb2222\x1fMerge branch branch1 into branch2 (gostacking)\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f2 days ago", nil
+			case command[0] == "log" && command[1] == "--merges":
+				return "", nil
+			case command[0] == "log" && command[len(command)-1] == "branch1...branch2":
+				return "aaa1111\x1fSome commit message\x1fJohn Doe\x1f2024-01-01T09:00:00-05:00\x1f3 days ago\n" +
+					"bbb2222\x1fMerge branch branch1 into branch2 (gostacking)\x1fJohn Doe\x1f2024-01-01T10:00:00-05:00\x1f2 days ago", nil
+			}
+			return "", nil
+		},
+	}
+	var messageReceived []string
+	stacksManager := StacksManagerForTest(gitExecutor, &messageReceived)
+
+	err := stacksManager.Tree(TreeOptions{ShowSyncs: true})
+
+	if err != nil {
+		t.Errorf("show have no error, got %s", err)
+	}
+	want := "log --reverse --right-only " + commitFormat + " branch1...branch2"
+	if !slices.Contains(gitCommands, want) {
+		t.Errorf("got %v, want %s", gitCommands, want)
+	}
+	for _, want := range []string{
+		"Never synced, up to date with its parent",
+		"Last sync 2 days ago, " + color.Red("parent moved: 3 commits to merge"),
+		color.DarkYellow("bbb2222") + " Merge branch branch1 into branch2 (gostacking) - 2 days ago " + color.Teal("← last sync"),
+	} {
+		if !strings.Contains(stacksManager.printerMessage(), want) {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.printerMessage(), want)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package stack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/printer"
+	"github.com/Bhacaz/gostacking/internal/terminal"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	paneStacks = iota
+	paneBranches
+)
+
+// uiMessageLines is the number of lines shown from the output of the last action
+const uiMessageLines = 6
+
+const uiHelp = "↑↓ select  ←→ pane  enter switch/checkout  c checkout  s sync  K/J move up/down  x remove  p publish  r refresh  q quit"
+
+// ui is the state of the terminal UI. The actions run the same StacksManager methods
+// as the commands, with their messages shown in the UI and their questions asked in it.
+type ui struct {
+	sm       StacksManager
+	term     terminal.Terminal
+	output   *bytes.Buffer
+	messages []string
+	focus    int
+	// stack and branch are the indexes of the selected stack and of its selected branch
+	stack  int
+	branch int
+	report StatusReport
+	// commits of the branches already selected, by branch
+	commits       map[string][]CommitReport
+	currentBranch string
+}
+
+// UI shows the stacks on the left and the branches of the selected stack on the right,
+// with their status and the commits of the selected branch, until q is pressed.
+func (sm StacksManager) UI(term terminal.Terminal) error {
+	u := &ui{term: term, output: &bytes.Buffer{}, sm: sm}
+	u.sm.printer = printer.NewPrinterTo(u.output)
+	u.sm.prompter = uiPrompter{ui: u}
+	u.sm.output = OutputText
+
+	sm.stacks.LoadStacks()
+	u.stack = max(0, slices.IndexFunc(sm.stacks.Stacks, func(stack Stack) bool {
+		return stack.Name == sm.stacks.CurrentStack
+	}))
+	u.reload()
+	if index := u.branchIndex(u.currentBranch); index != -1 {
+		u.branch = index
+		u.focus = paneBranches
+	}
+
+	for {
+		u.render()
+		key, err := term.ReadKey()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !u.handle(key) {
+			return nil
+		}
+	}
+}
+
+// handle runs the action of a key, false to quit.
+func (u *ui) handle(key terminal.Key) bool {
+	switch key {
+	case "q", terminal.KeyCtrlC, terminal.KeyEscape:
+		return false
+	case terminal.KeyUp, "k":
+		u.moveSelection(-1)
+	case terminal.KeyDown, "j":
+		u.moveSelection(1)
+	case terminal.KeyLeft, "h":
+		u.focus = paneStacks
+	case terminal.KeyRight, "l":
+		u.focus = paneBranches
+	case terminal.KeyTab:
+		u.focus = 1 - u.focus
+	case terminal.KeyEnter:
+		if u.focus == paneStacks {
+			u.switchStack()
+			u.focus = paneBranches
+		} else {
+			u.checkout()
+		}
+	case "c":
+		u.checkout()
+	case "s":
+		u.sync()
+	case "K":
+		u.move(-1)
+	case "J":
+		u.move(1)
+	case "x":
+		u.remove()
+	case "p":
+		u.publish()
+	case "r":
+		u.run("Fetching", u.sm.fetch)
+	}
+	return true
+}
+
+func (u *ui) selectedStack() *Stack {
+	if len(u.sm.stacks.Stacks) == 0 {
+		return nil
+	}
+	return &u.sm.stacks.Stacks[u.stack]
+}
+
+func (u *ui) selectedBranch() string {
+	if len(u.report.Branches) == 0 {
+		return ""
+	}
+	return u.report.Branches[u.branch].Name
+}
+
+func (u *ui) branchIndex(branch string) int {
+	return slices.IndexFunc(u.report.Branches, func(report BranchReport) bool {
+		return report.Name == branch
+	})
+}
+
+func (u *ui) moveSelection(delta int) {
+	if u.focus == paneBranches {
+		u.branch = clampIndex(u.branch+delta, len(u.report.Branches))
+		return
+	}
+
+	index := clampIndex(u.stack+delta, len(u.sm.stacks.Stacks))
+	if index != u.stack {
+		u.stack = index
+		u.branch = 0
+		u.loadReport()
+	}
+}
+
+func (u *ui) switchStack() {
+	stack := u.selectedStack()
+	if stack == nil {
+		return
+	}
+	u.run("Switching to "+stack.Name, func() error {
+		return u.sm.SwitchByName(stack.Name)
+	})
+}
+
+func (u *ui) checkout() {
+	branch := u.selectedBranch()
+	if branch == "" {
+		return
+	}
+	u.run("Checking out "+branch, u.onSelectedStack(func() error {
+		return u.sm.CheckoutByName(branch)
+	}))
+}
+
+func (u *ui) sync() {
+	stack := u.selectedStack()
+	if stack == nil {
+		return
+	}
+	u.run("Syncing "+stack.Name, u.onSelectedStack(func() error {
+		return u.sm.Sync(u.sm.config.Sync.Push, u.sm.config.Sync.MergeDefault)
+	}))
+}
+
+// move moves the selected branch up (-1) or down (1) in its stack.
+func (u *ui) move(delta int) {
+	branch := u.selectedBranch()
+	position := u.branch + 1 + delta
+	if branch == "" || position < 1 || position > len(u.report.Branches) {
+		return
+	}
+	u.run("Moving "+branch, u.onSelectedStack(func() error {
+		return u.sm.MoveByName(branch, position)
+	}))
+	if index := u.branchIndex(branch); index != -1 {
+		u.branch = index
+	}
+}
+
+func (u *ui) remove() {
+	branch := u.selectedBranch()
+	if branch == "" {
+		return
+	}
+	u.output.Reset()
+	if !u.sm.prompter.Confirm("Remove " + branch + " from " + u.selectedStack().Name + "?") {
+		u.messages = nil
+		return
+	}
+	u.run("Removing "+branch, u.onSelectedStack(func() error {
+		return u.sm.RemoveByName(branch)
+	}))
+}
+
+// publish checks out the selected branch, Publish works on the current branch.
+func (u *ui) publish() {
+	branch := u.selectedBranch()
+	if branch == "" {
+		return
+	}
+	u.run("Publishing "+branch, u.onSelectedStack(func() error {
+		err := u.sm.CheckoutByName(branch)
+		if err != nil {
+			return err
+		}
+		return u.sm.Publish()
+	}))
+}
+
+// onSelectedStack switches to the selected stack before the action,
+// the actions work on the current stack like the commands.
+func (u *ui) onSelectedStack(action func() error) func() error {
+	return func() error {
+		stack := u.selectedStack()
+		if stack.Name != u.sm.stacks.CurrentStack {
+			err := u.sm.SwitchByName(stack.Name)
+			if err != nil {
+				return err
+			}
+		}
+		return action()
+	}
+}
+
+// run shows the title while the action runs, then its output and reloads the stacks.
+func (u *ui) run(title string, action func() error) {
+	u.output.Reset()
+	u.messages = []string{title + "..."}
+	u.render()
+
+	err := action()
+	u.messages = outputLines(u.output.String())
+	if err != nil {
+		u.messages = append(u.messages, outputLines(color.Red(err.Error()))...)
+	} else if len(u.messages) == 0 {
+		u.messages = []string{title + "... done"}
+	}
+	u.reload()
+}
+
+func (u *ui) reload() {
+	u.sm.stacks.LoadStacks()
+	u.stack = clampIndex(u.stack, len(u.sm.stacks.Stacks))
+	u.loadReport()
+}
+
+func (u *ui) loadReport() {
+	u.report = StatusReport{}
+	u.commits = map[string][]CommitReport{}
+	if stack := u.selectedStack(); stack != nil {
+		u.report = u.sm.stackStatusReport(stack.Name, false)
+	}
+	u.branch = clampIndex(u.branch, len(u.report.Branches))
+	u.currentBranch, _ = u.sm.currentBranchName()
+}
+
+// selectedCommits are the commits of the selected branch not in its parent.
+func (u *ui) selectedCommits() []CommitReport {
+	branch := u.selectedBranch()
+	if commits, ok := u.commits[branch]; ok {
+		return commits
+	}
+	parent := u.report.Branches[u.branch].Parent
+	if parent == "" {
+		return nil
+	}
+	commits, _ := u.sm.commitsBetweenBranches(parent, branch)
+	u.commits[branch] = commits
+	return commits
+}
+
+func (u *ui) render() {
+	width, height := u.term.Size()
+	leftWidth := min(max(width/3, 20), width/2)
+	rightWidth := width - leftWidth - 1
+
+	left := []string{u.title("Stacks", paneStacks)}
+	for i, stack := range u.sm.stacks.Stacks {
+		left = append(left, u.marker(paneStacks, i == u.stack)+color.Green(stack.Name))
+	}
+
+	var right []string
+	if stack := u.selectedStack(); stack == nil {
+		right = append(right, "No stack found, use `"+color.Magenta("gostacking new <stackname>")+"` to create a stack")
+	} else {
+		right = append(right, u.title("Branches of "+stack.Name, paneBranches))
+	}
+	for i, branch := range u.report.Branches {
+		line := u.marker(paneBranches, i == u.branch) + fmt.Sprintf("%d. "+color.Yellow(branch.Name), branch.Position) + branch.Status.Symbols()
+		if branch.Name == u.currentBranch {
+			line += " " + color.Teal("(checked out)")
+		}
+		right = append(right, line)
+	}
+	selectedLine := u.branch + 1
+	if branch := u.selectedBranch(); branch != "" {
+		right = append(right, "", "Commits of "+color.Yellow(branch)+" not in "+u.report.Branches[u.branch].Parent+":")
+		commits := u.selectedCommits()
+		if len(commits) == 0 {
+			right = append(right, "  No commits")
+		}
+		for _, commit := range commits {
+			right = append(right, "  "+color.DarkYellow(commit.Hash)+" "+commit.Subject+" - "+commit.RelativeDate)
+		}
+	}
+
+	messages := u.messages[max(0, len(u.messages)-uiMessageLines):]
+	help := wrapHelp(uiHelp, width)
+	bodyHeight := max(height-1-len(messages)-len(help), 1)
+	left = scrollTo(left, u.stack+1, bodyHeight)
+	right = scrollTo(right, selectedLine, bodyHeight)
+
+	screen := []string{fitWidth("Current stack: "+color.Green(u.sm.stacks.CurrentStack)+"  Current branch: "+color.Yellow(u.currentBranch), width)}
+	for row := 0; row < bodyHeight; row++ {
+		screen = append(screen, fitWidth(lineAt(left, row), leftWidth)+"│"+fitWidth(lineAt(right, row), rightWidth))
+	}
+	for _, message := range messages {
+		screen = append(screen, fitWidth(message, width))
+	}
+	for _, line := range help {
+		screen = append(screen, fitWidth(line, width))
+	}
+
+	_, _ = io.WriteString(u.term, terminal.ClearScreen+strings.Join(screen, "\r\n"))
+}
+
+func (u *ui) title(text string, pane int) string {
+	if u.focus == pane {
+		return color.Teal(text)
+	}
+	return text
+}
+
+// marker shows the selected line, > in the focused pane.
+func (u *ui) marker(pane int, selected bool) string {
+	if !selected {
+		return "  "
+	}
+	if u.focus == pane {
+		return "> "
+	}
+	return "• "
+}
+
+// uiPrompter asks the questions of the actions (e.g. sync after a move) in the UI.
+type uiPrompter struct {
+	ui *ui
+}
+
+func (p uiPrompter) Confirm(question string) bool {
+	p.ui.messages = append(outputLines(p.ui.output.String()), question+" [y/N]")
+	p.ui.render()
+	key, err := p.ui.term.ReadKey()
+	return err == nil && (key == "y" || key == "Y")
+}
+
+// wrapHelp splits the keys of the help on lines of width.
+func wrapHelp(help string, width int) []string {
+	var lines []string
+	line := ""
+	for _, key := range strings.Split(help, "  ") {
+		if line != "" && len([]rune(line+"  "+key)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += "  "
+		}
+		line += key
+	}
+	return append(lines, line)
+}
+
+func outputLines(output string) []string {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(output, "\t", "    "), "\n")
+}
+
+func clampIndex(index int, length int) int {
+	if length == 0 {
+		return 0
+	}
+	return min(max(index, 0), length-1)
+}
+
+// scrollTo keeps height lines with the selected one visible.
+func scrollTo(lines []string, selected int, height int) []string {
+	start := max(0, selected-height+1)
+	if start >= len(lines) {
+		return nil
+	}
+	return lines[start:min(len(lines), start+height)]
+}
+
+func lineAt(lines []string, row int) string {
+	if row < len(lines) {
+		return lines[row]
+	}
+	return ""
+}
+
+// fitWidth truncates or pads the line to width visible characters, the color escapes are kept.
+func fitWidth(line string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	var fitted strings.Builder
+	visible := 0
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\033' {
+			end := i
+			for end < len(runes)-1 && runes[end] != 'm' {
+				end++
+			}
+			fitted.WriteString(string(runes[i : end+1]))
+			i = end
+			continue
+		}
+		if visible == width {
+			if color.Enabled() {
+				fitted.WriteString("\033[0m")
+			}
+			break
+		}
+		fitted.WriteRune(runes[i])
+		visible++
+	}
+	fitted.WriteString(strings.Repeat(" ", width-visible))
+	return fitted.String()
+}
@@ -0,0 +1,227 @@
+package stack
+
+import (
+	"github.com/Bhacaz/gostacking/internal/color"
+	"github.com/Bhacaz/gostacking/internal/terminal"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// uiStacksManager is on branch2 of stack1, branch2 is 2 commits behind branch1.
+func uiStacksManager(t *testing.T) (StacksManager, *[]string) {
+	t.Cleanup(func() { _ = color.SetMode(color.ModeAuto) })
+	_ = color.SetMode(color.ModeNever)
+
+	var gitCommands []string
+	var mutex sync.Mutex
+	gitExecutor := cliExecutorStub{
+		stubExec: func(command ...string) (string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			gitCommands = append(gitCommands, strings.Join(command, " "))
+			switch {
+			case slices.Equal(command, []string{"rev-parse", "--abbrev-ref", "HEAD"}):
+				return "branch2", nil
+			case command[0] == "symbolic-ref":
+				return "origin/main", nil
+			case command[0] == "rev-list" && command[len(command)-1] == "branch2...branch1":
+				return "0\t2", nil
+			case command[0] == "rev-list":
+				return "0\t0", nil
+			case command[0] == "log":
+				return "abc1234\x1fAdd feature\x1fJohn Doe\x1f2024-01-01T12:00:00+00:00\x1f2 days ago", nil
+			}
+			return "", nil
+		},
+	}
+	var messageReceived []string
+	return StacksManagerForTest(gitExecutor, &messageReceived), &gitCommands
+}
+
+func TestStacksManager_UI(t *testing.T) {
+	t.Run("shows the stacks and the branches of the current stack", func(t *testing.T) {
+		stacksManager, _ := uiStacksManager(t)
+		term := terminal.NewFake(100, 20)
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		screen := term.Screen()
+		for _, want := range []string{
+			"Current stack: stack1  Current branch: branch2",
+			"• stack1",
+			"  stack2",
+			"  1. branch1",
+			"> 2. branch2 ⇣2 (checked out)",
+			"Commits of branch2 not in branch1:",
+			"abc1234 Add feature - 2 days ago",
+		} {
+			if !strings.Contains(screen, want) {
+				t.Errorf("got \"%s\", want \"%s\"", screen, want)
+			}
+		}
+	})
+
+	t.Run("checkout the selected branch", func(t *testing.T) {
+		stacksManager, gitCommands := uiStacksManager(t)
+		term := terminal.NewFake(100, 20, terminal.KeyUp, terminal.KeyEnter)
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if !slices.Contains(*gitCommands, "checkout branch1") {
+			t.Errorf("got %v, want checkout branch1", *gitCommands)
+		}
+		if !strings.Contains(term.Screen(), "Checking out branch1... done") {
+			t.Errorf("got \"%s\", want \"%s\"", term.Screen(), "Checking out branch1... done")
+		}
+	})
+
+	t.Run("switch to another stack then checkout its branch", func(t *testing.T) {
+		stacksManager, gitCommands := uiStacksManager(t)
+		term := terminal.NewFake(100, 20, terminal.KeyLeft, "j", terminal.KeyEnter, terminal.KeyEnter)
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if stacksManager.stacks.CurrentStack != "stack2" {
+			t.Errorf("got \"%s\", want \"%s\"", stacksManager.stacks.CurrentStack, "stack2")
+		}
+		if !slices.Contains(*gitCommands, "checkout branch3") {
+			t.Errorf("got %v, want checkout branch3", *gitCommands)
+		}
+		if !strings.Contains(term.Screen(), "Branches of stack2") {
+			t.Errorf("got \"%s\", want \"%s\"", term.Screen(), "Branches of stack2")
+		}
+	})
+
+	t.Run("remove the selected branch when confirmed", func(t *testing.T) {
+		stacksManager, _ := uiStacksManager(t)
+		term := terminal.NewFake(100, 20, "x", "y")
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		if !reflect.DeepEqual(branches, []string{"branch1"}) {
+			t.Errorf("got %v, want %v", branches, []string{"branch1"})
+		}
+		screens := term.Screens()
+		if !strings.Contains(screens[1], "Remove branch2 from stack1? [y/N]") {
+			t.Errorf("got \"%s\", want \"%s\"", screens[1], "Remove branch2 from stack1? [y/N]")
+		}
+	})
+
+	t.Run("keep the selected branch when not confirmed", func(t *testing.T) {
+		stacksManager, _ := uiStacksManager(t)
+		term := terminal.NewFake(100, 20, "x", "n")
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		if !reflect.DeepEqual(branches, []string{"branch1", "branch2"}) {
+			t.Errorf("got %v, want %v", branches, []string{"branch1", "branch2"})
+		}
+	})
+
+	t.Run("move the selected branch up", func(t *testing.T) {
+		stacksManager, gitCommands := uiStacksManager(t)
+		// n to the question to run sync
+		term := terminal.NewFake(100, 20, "K", "n")
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		branches, _ := stacksManager.stacks.GetBranchesByName("stack1")
+		if !reflect.DeepEqual(branches, []string{"branch2", "branch1"}) {
+			t.Errorf("got %v, want %v", branches, []string{"branch2", "branch1"})
+		}
+		if !strings.Contains(term.Screen(), "> 1. branch2") {
+			t.Errorf("got \"%s\", want \"%s\"", term.Screen(), "> 1. branch2")
+		}
+		if slices.Contains(*gitCommands, "fetch origin") {
+			t.Errorf("got %v, want no sync", *gitCommands)
+		}
+	})
+
+	t.Run("sync the selected stack", func(t *testing.T) {
+		stacksManager, gitCommands := uiStacksManager(t)
+		term := terminal.NewFake(100, 20, "s")
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		for _, want := range []string{"fetch origin", "checkout branch1", "merge branch1 -m Merge branch branch1 into branch2 (gostacking)"} {
+			if !slices.Contains(*gitCommands, want) {
+				t.Errorf("got %v, want %s", *gitCommands, want)
+			}
+		}
+		// The title while syncing, then the last lines of the output
+		screens := term.Screens()
+		if !strings.Contains(screens[1], "Syncing stack1...") {
+			t.Errorf("got \"%s\", want \"%s\"", screens[1], "Syncing stack1...")
+		}
+		if !strings.Contains(term.Screen(), "    Merging branch1") {
+			t.Errorf("got \"%s\", want \"%s\"", term.Screen(), "    Merging branch1")
+		}
+	})
+
+	t.Run("quit with q", func(t *testing.T) {
+		stacksManager, gitCommands := uiStacksManager(t)
+		term := terminal.NewFake(100, 20, "q", "c")
+
+		err := stacksManager.UI(term)
+
+		if err != nil {
+			t.Errorf("show have no error, got %s", err)
+		}
+		if slices.ContainsFunc(*gitCommands, func(command string) bool { return strings.HasPrefix(command, "checkout") }) {
+			t.Errorf("got %v, want no checkout", *gitCommands)
+		}
+	})
+}
+
+func TestFitWidth(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc  "},
+		{"abcdef", 3, "abc"},
+		{"⇣2 é", 4, "⇣2 é"},
+		{"abc", 0, ""},
+	}
+	for _, test := range tests {
+		got := fitWidth(test.line, test.width)
+		if got != test.want {
+			t.Errorf("got \"%s\", want \"%s\"", got, test.want)
+		}
+	}
+}
+
+func TestWrapHelp(t *testing.T) {
+	got := wrapHelp("a b  c d  e", 8)
+	want := []string{"a b  c d", "e"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
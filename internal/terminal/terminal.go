@@ -0,0 +1,164 @@
+package terminal
+
+import (
+	"errors"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+)
+
+// Key is a key pressed by the user: one of the constants below,
+// or the character itself for the other keys (e.g. "j").
+type Key string
+
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyLeft      Key = "left"
+	KeyRight     Key = "right"
+	KeyEnter     Key = "enter"
+	KeyTab       Key = "tab"
+	KeyEscape    Key = "escape"
+	KeyBackspace Key = "backspace"
+	KeyCtrlC     Key = "ctrl+c"
+)
+
+const (
+	ClearScreen = "\033[H\033[2J"
+	altScreen   = "\033[?1049h\033[?25l"
+	mainScreen  = "\033[?25h\033[?1049l"
+)
+
+// Terminal is a full screen terminal, the real one or a Fake in tests.
+type Terminal interface {
+	io.Writer
+	ReadKey() (Key, error)
+	// Size is the number of columns and rows
+	Size() (int, int)
+}
+
+type Tty struct {
+	in    *os.File
+	out   *os.File
+	state *term.State
+}
+
+// Open switches the terminal to raw mode on the alternate screen, Close restores it.
+func Open() (*Tty, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, errors.New("not a terminal")
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, errors.New("failed to set the terminal in raw mode\n" + err.Error())
+	}
+	tty := &Tty{in: os.Stdin, out: os.Stdout, state: state}
+	_, _ = tty.out.WriteString(altScreen)
+	return tty, nil
+}
+
+func (t *Tty) Close() error {
+	_, _ = t.out.WriteString(mainScreen)
+	return term.Restore(int(t.in.Fd()), t.state)
+}
+
+func (t *Tty) Write(p []byte) (int, error) {
+	return t.out.Write(p)
+}
+
+func (t *Tty) Size() (int, int) {
+	width, height, err := term.GetSize(int(t.out.Fd()))
+	// Some terminals (e.g. a pseudo terminal without a window) have no size
+	if err != nil || width == 0 || height == 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// ReadKey blocks until a key is pressed. An escape sequence is
+// expected to come in a single read, as terminals send it.
+func (t *Tty) ReadKey() (Key, error) {
+	buffer := make([]byte, 16)
+	n, err := t.in.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+	return parseKey(buffer[:n]), nil
+}
+
+func parseKey(input []byte) Key {
+	switch string(input) {
+	case "\033[A", "\033OA":
+		return KeyUp
+	case "\033[B", "\033OB":
+		return KeyDown
+	case "\033[C", "\033OC":
+		return KeyRight
+	case "\033[D", "\033OD":
+		return KeyLeft
+	case "\r", "\n":
+		return KeyEnter
+	case "\t":
+		return KeyTab
+	case "\033":
+		return KeyEscape
+	case "\177", "\b":
+		return KeyBackspace
+	case "\003":
+		return KeyCtrlC
+	}
+	// Unknown escape sequences (e.g. function keys) are ignored
+	if strings.HasPrefix(string(input), "\033") {
+		return ""
+	}
+	return Key(input)
+}
+
+// Fake is a terminal for tests: it returns the given keys then io.EOF
+// and keeps everything written to it.
+type Fake struct {
+	Width  int
+	Height int
+	keys   []Key
+	output strings.Builder
+}
+
+func NewFake(width int, height int, keys ...Key) *Fake {
+	return &Fake{Width: width, Height: height, keys: keys}
+}
+
+func (f *Fake) Write(p []byte) (int, error) {
+	return f.output.Write(p)
+}
+
+func (f *Fake) Size() (int, int) {
+	return f.Width, f.Height
+}
+
+func (f *Fake) ReadKey() (Key, error) {
+	if len(f.keys) == 0 {
+		return "", io.EOF
+	}
+	key := f.keys[0]
+	f.keys = f.keys[1:]
+	return key, nil
+}
+
+// Screens returns every screen drawn, one per ClearScreen, with raw mode line endings as \n.
+func (f *Fake) Screens() []string {
+	var screens []string
+	for _, screen := range strings.Split(f.output.String(), ClearScreen)[1:] {
+		screens = append(screens, strings.ReplaceAll(screen, "\r\n", "\n"))
+	}
+	return screens
+}
+
+// Screen is the last screen drawn.
+func (f *Fake) Screen() string {
+	screens := f.Screens()
+	if len(screens) == 0 {
+		return ""
+	}
+	return screens[len(screens)-1]
+}
@@ -0,0 +1,58 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Key
+	}{
+		{"\033[A", KeyUp},
+		{"\033OB", KeyDown},
+		{"\033[C", KeyRight},
+		{"\033[D", KeyLeft},
+		{"\r", KeyEnter},
+		{"\t", KeyTab},
+		{"\033", KeyEscape},
+		{"\177", KeyBackspace},
+		{"\003", KeyCtrlC},
+		{"j", "j"},
+		{"é", "é"},
+		// F1
+		{"\033OP", ""},
+	}
+	for _, test := range tests {
+		got := parseKey([]byte(test.input))
+		if got != test.want {
+			t.Errorf("%q: got \"%s\", want \"%s\"", test.input, got, test.want)
+		}
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake(80, 24, "j", KeyEnter)
+
+	for _, want := range []Key{"j", KeyEnter} {
+		key, err := fake.ReadKey()
+		if err != nil || key != want {
+			t.Errorf("got \"%s\" %v, want \"%s\"", key, err, want)
+		}
+	}
+	_, err := fake.ReadKey()
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+
+	_, _ = io.WriteString(fake, ClearScreen+"first\r\nscreen")
+	_, _ = io.WriteString(fake, ClearScreen+"second")
+	if len(fake.Screens()) != 2 || fake.Screens()[0] != "first\nscreen" {
+		t.Errorf("got %q, want 2 screens", fake.Screens())
+	}
+	if fake.Screen() != "second" {
+		t.Errorf("got \"%s\", want \"%s\"", fake.Screen(), "second")
+	}
+}